@@ -0,0 +1,300 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+// AuditEvent records one request sent to a managed cluster
+type AuditEvent struct {
+	Timestamp time.Time                   `json:"timestamp"`
+	Cluster   string                      `json:"cluster"`
+	Verb      string                      `json:"verb"`
+	Resource  schema.GroupVersionResource `json:"resource"`
+	Namespace string                      `json:"namespace,omitempty"`
+	Name      string                      `json:"name,omitempty"`
+	// User the identity of the caller, taken from the impersonation header,
+	// the request context or the identity of the transport in order
+	User string `json:"user,omitempty"`
+	// Groups the groups impersonated by the caller
+	Groups  []string      `json:"groups,omitempty"`
+	Latency time.Duration `json:"latency"`
+	// Code the status code of the response, 0 if no response received
+	Code  int    `json:"code"`
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink records the audit events
+type AuditSink interface {
+	Record(event AuditEvent)
+}
+
+// WithAuditSink records every request sent to managed clusters by the
+// transport into the sink
+type WithAuditSink struct {
+	AuditSink
+}
+
+// ApplyToTransport .
+func (op WithAuditSink) ApplyToTransport(t *Transport) {
+	t.auditSink = op.AuditSink
+}
+
+// WithAuditIdentity the identity of the transport recorded as the user of
+// the audit events for requests without impersonation or request user
+type WithAuditIdentity string
+
+// ApplyToTransport .
+func (op WithAuditIdentity) ApplyToTransport(t *Transport) {
+	t.auditIdentity = string(op)
+}
+
+// GetConfigIdentity returns the identity authenticated by the config, which
+// is the impersonated user, the basic auth username, the subject of the
+// bearer token (such as the service account) or the common name of the client
+// certificate in order. It returns empty string if not found.
+func GetConfigIdentity(config *rest.Config) string {
+	if config.Impersonate.UserName != "" {
+		return config.Impersonate.UserName
+	}
+	if config.Username != "" {
+		return config.Username
+	}
+	token := config.BearerToken
+	if token == "" && config.BearerTokenFile != "" {
+		if bs, err := os.ReadFile(config.BearerTokenFile); err == nil {
+			token = string(bs)
+		}
+	}
+	if subject := getTokenSubject(token); subject != "" {
+		return subject
+	}
+	certData := config.CertData
+	if len(certData) == 0 && config.CertFile != "" {
+		certData, _ = os.ReadFile(config.CertFile)
+	}
+	if block, _ := pem.Decode(certData); block != nil {
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			return cert.Subject.CommonName
+		}
+	}
+	return ""
+}
+
+// getTokenSubject reads the sub claim of the JWT without verifying it
+func getTokenSubject(token string) string {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	claims := struct {
+		Sub string `json:"sub"`
+	}{}
+	_ = json.Unmarshal(payload, &claims)
+	return claims.Sub
+}
+
+// newAuditEvent parses the request into the audit event
+func newAuditEvent(cluster string, req *http.Request, identity string) AuditEvent {
+	event := AuditEvent{
+		Timestamp: time.Now(),
+		Cluster:   cluster,
+		User:      req.Header.Get("Impersonate-User"),
+		Groups:    req.Header.Values("Impersonate-Group"),
+	}
+	if event.User == "" {
+		event.User, _ = RequestUserFrom(req.Context())
+	}
+	if event.User == "" {
+		event.User = identity
+	}
+	// path format: /api/{version}/... or /apis/{group}/{version}/...
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		event.Resource.Version, segments = segments[1], segments[2:]
+	case len(segments) >= 3 && segments[0] == "apis":
+		event.Resource.Group, event.Resource.Version, segments = segments[1], segments[2], segments[3:]
+	default:
+		segments = nil
+	}
+	if len(segments) >= 2 && segments[0] == "namespaces" {
+		if len(segments) == 2 {
+			// the namespace object itself
+			event.Resource.Resource, event.Name = "namespaces", segments[1]
+			segments = nil
+		} else {
+			event.Namespace, segments = segments[1], segments[2:]
+		}
+	}
+	if len(segments) > 0 {
+		event.Resource.Resource = segments[0]
+	}
+	if len(segments) > 1 {
+		event.Name = segments[1]
+	}
+	if len(segments) > 2 {
+		// subresource like pods/status
+		event.Resource.Resource += "/" + strings.Join(segments[2:], "/")
+	}
+	event.Verb = getVerb(req, event.Name)
+	return event
+}
+
+// getVerb converts the http method into the Kubernetes verb
+func getVerb(req *http.Request, name string) string {
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("watch") == "true" {
+			return "watch"
+		}
+		if name == "" {
+			return "list"
+		}
+		return "get"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		if name == "" {
+			return "deletecollection"
+		}
+		return "delete"
+	default:
+		return strings.ToLower(req.Method)
+	}
+}
+
+// LogAuditSink records the audit events into logs
+type LogAuditSink struct{}
+
+// Record .
+func (s LogAuditSink) Record(event AuditEvent) {
+	klog.InfoS("multicluster request audit",
+		"cluster", event.Cluster,
+		"verb", event.Verb,
+		"resource", event.Resource.String(),
+		"namespace", event.Namespace,
+		"name", event.Name,
+		"user", event.User,
+		"groups", event.Groups,
+		"latency", event.Latency,
+		"code", event.Code,
+		"error", event.Error)
+}
+
+// WebhookAuditSink sends the audit events to the webhook in batches. Events
+// are dropped if the buffer is full.
+type WebhookAuditSink struct {
+	url    string
+	client *http.Client
+	events chan AuditEvent
+}
+
+// DefaultWebhookAuditSinkBufferSize the default size of the event buffer for
+// WebhookAuditSink
+const DefaultWebhookAuditSinkBufferSize = 1024
+
+// NewWebhookAuditSink create a WebhookAuditSink which posts audit events as
+// JSON array to the url until the context is done. If client is nil,
+// http.DefaultClient will be used.
+func NewWebhookAuditSink(ctx context.Context, url string, client *http.Client) *WebhookAuditSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	s := &WebhookAuditSink{
+		url:    url,
+		client: client,
+		events: make(chan AuditEvent, DefaultWebhookAuditSinkBufferSize),
+	}
+	go s.run(ctx)
+	return s
+}
+
+// Record .
+func (s *WebhookAuditSink) Record(event AuditEvent) {
+	select {
+	case s.events <- event:
+	default:
+		klog.Warningf("audit event buffer is full, drop event for %s %s in cluster %s", event.Verb, event.Resource.String(), event.Cluster)
+	}
+}
+
+func (s *WebhookAuditSink) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-s.events:
+			batch := []AuditEvent{event}
+			for more := true; more; {
+				select {
+				case event = <-s.events:
+					batch = append(batch, event)
+				default:
+					more = false
+				}
+			}
+			if err := s.send(ctx, batch); err != nil {
+				klog.ErrorS(err, "failed to send audit events", "count", len(batch))
+			}
+		}
+	}
+}
+
+func (s *WebhookAuditSink) send(ctx context.Context, events []AuditEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
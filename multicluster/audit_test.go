@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+type auditRecorder struct {
+	mu     sync.Mutex
+	events []AuditEvent
+}
+
+func (r *auditRecorder) Record(event AuditEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *auditRecorder) get() []AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]AuditEvent{}, r.events...)
+}
+
+func TestNewAuditEvent(t *testing.T) {
+	testcases := map[string]struct {
+		method   string
+		path     string
+		expected AuditEvent
+	}{
+		"get-core": {
+			method: http.MethodGet,
+			path:   "/api/v1/namespaces/default/configmaps/example",
+			expected: AuditEvent{Verb: "get", Namespace: "default", Name: "example",
+				Resource: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}},
+		},
+		"list-group": {
+			method: http.MethodGet,
+			path:   "/apis/apps/v1/deployments",
+			expected: AuditEvent{Verb: "list",
+				Resource: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}},
+		},
+		"update-status": {
+			method: http.MethodPut,
+			path:   "/apis/apps/v1/namespaces/default/deployments/example/status",
+			expected: AuditEvent{Verb: "update", Namespace: "default", Name: "example",
+				Resource: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments/status"}},
+		},
+		"delete-namespace": {
+			method: http.MethodDelete,
+			path:   "/api/v1/namespaces/default",
+			expected: AuditEvent{Verb: "delete", Name: "default",
+				Resource: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}},
+		},
+		"delete-collection": {
+			method: http.MethodDelete,
+			path:   "/api/v1/namespaces/default/pods",
+			expected: AuditEvent{Verb: "deletecollection", Namespace: "default",
+				Resource: schema.GroupVersionResource{Version: "v1", Resource: "pods"}},
+		},
+	}
+	for name, tt := range testcases {
+		t.Run(name, func(t *testing.T) {
+			req := &http.Request{Method: tt.method, URL: &url.URL{Path: tt.path}, Header: http.Header{}}
+			event := newAuditEvent("example", req, "")
+			event.Timestamp = time.Time{}
+			tt.expected.Cluster = "example"
+			require.Equal(t, tt.expected, event)
+		})
+	}
+}
+
+func TestTransportAudit(t *testing.T) {
+	r := require.New(t)
+	recorder := &auditRecorder{}
+	rt := NewTransportWrapper(WithAuditSink{recorder})(&fakeRoundTripper{})
+	req := &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/api/v1/pods", RawQuery: "watch=true"}, Header: http.Header{}}
+	req.Header.Set("Impersonate-User", "alice")
+	_, err := rt.RoundTrip(req.WithContext(WithCluster(context.Background(), "example")))
+	r.NoError(err)
+	_, err = rt.RoundTrip(req.WithContext(context.Background()))
+	r.NoError(err)
+	events := recorder.get()
+	r.Equal(1, len(events))
+	r.Equal("example", events[0].Cluster)
+	r.Equal("watch", events[0].Verb)
+	r.Equal("alice", events[0].User)
+	r.Equal("pods", events[0].Resource.Resource)
+}
+
+func TestAuditEventUser(t *testing.T) {
+	r := require.New(t)
+	req := &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/api/v1/pods"}, Header: http.Header{}}
+	r.Equal("system:serviceaccount:vela-system:vela", newAuditEvent("example", req, "system:serviceaccount:vela-system:vela").User)
+	req = req.WithContext(WithRequestUser(context.Background(), "bob"))
+	r.Equal("bob", newAuditEvent("example", req, "system:serviceaccount:vela-system:vela").User)
+	req.Header.Set("Impersonate-User", "alice")
+	req.Header.Add("Impersonate-Group", "dev")
+	req.Header.Add("Impersonate-Group", "ops")
+	event := newAuditEvent("example", req, "system:serviceaccount:vela-system:vela")
+	r.Equal("alice", event.User)
+	r.Equal([]string{"dev", "ops"}, event.Groups)
+}
+
+func TestGetConfigIdentity(t *testing.T) {
+	r := require.New(t)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"system:serviceaccount:vela-system:vela"}`))
+	r.Equal("system:serviceaccount:vela-system:vela", GetConfigIdentity(&rest.Config{BearerToken: "x." + payload + ".y"}))
+	r.Equal("admin", GetConfigIdentity(&rest.Config{Username: "admin", BearerToken: "x." + payload + ".y"}))
+	r.Equal("alice", GetConfigIdentity(&rest.Config{Username: "admin", Impersonate: rest.ImpersonationConfig{UserName: "alice"}}))
+	r.Equal("", GetConfigIdentity(&rest.Config{BearerToken: "opaque"}))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	r.NoError(err)
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "cluster-admin"},
+		NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	r.NoError(err)
+	certData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	r.Equal("cluster-admin", GetConfigIdentity(&rest.Config{TLSClientConfig: rest.TLSClientConfig{CertData: certData}}))
+}
+
+func TestWebhookAuditSink(t *testing.T) {
+	recorder := &auditRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var events []AuditEvent
+		if err := json.NewDecoder(req.Body).Decode(&events); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, event := range events {
+			recorder.Record(event)
+		}
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sink := NewWebhookAuditSink(ctx, server.URL, nil)
+	sink.Record(AuditEvent{Cluster: "a", Verb: "get"})
+	sink.Record(AuditEvent{Cluster: "b", Verb: "list"})
+	require.Eventually(t, func() bool { return len(recorder.get()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "b", recorder.get()[1].Cluster)
+	LogAuditSink{}.Record(AuditEvent{Cluster: "a"})
+}
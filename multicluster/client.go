@@ -111,6 +111,8 @@ func (m *gatedStatusWriter) Patch(ctx context.Context, obj client.Object, patch
 type ClientOptions struct {
 	client.Options
 	ClusterGateway ClusterGatewayClientOptions
+	// AuditSink if set, records all the requests to managed clusters
+	AuditSink AuditSink
 }
 
 // ClusterGatewayClientOptions the options for creating the gateway client
@@ -133,8 +135,12 @@ type ClusterGatewayClientOptions struct {
 // for managed cluster requests, instead of calling the hub Kubernetes
 // APIServer.
 func NewClient(config *rest.Config, options ClientOptions) (client.Client, error) {
+	var transportOptions []TransportOption
+	if options.AuditSink != nil {
+		transportOptions = append(transportOptions, WithAuditSink{options.AuditSink}, WithAuditIdentity(GetConfigIdentity(config)))
+	}
 	wrapped := rest.CopyConfig(config)
-	wrapped.Wrap(NewTransportWrapper())
+	wrapped.Wrap(NewTransportWrapper(transportOptions...))
 	if len(options.ClusterGateway.URL) == 0 {
 		return client.New(wrapped, options.Options)
 	}
@@ -23,6 +23,11 @@ type key int
 const (
 	// clusterKey is the context key for multi-cluster request
 	clusterKey key = iota
+	// readPreferenceKey is the context key for the read preference
+	readPreferenceKey
+	// requestUserKey is the context key for the user on whose behalf the
+	// request is sent
+	requestUserKey
 )
 
 // WithCluster returns a copy of parent in which the cluster value is set
@@ -35,3 +40,15 @@ func ClusterFrom(ctx context.Context) (string, bool) {
 	cluster, ok := ctx.Value(clusterKey).(string)
 	return cluster, ok
 }
+
+// WithRequestUser returns a copy of parent in which the user sending the
+// request is set. The user is recorded in the audit events.
+func WithRequestUser(parent context.Context, user string) context.Context {
+	return context.WithValue(parent, requestUserKey, user)
+}
+
+// RequestUserFrom returns the value of the request user key on the ctx
+func RequestUserFrom(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(requestUserKey).(string)
+	return user, ok
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// DefaultClusterSecretNamespace the default namespace of the cluster
+	// credential secrets
+	DefaultClusterSecretNamespace = "vela-system"
+	// LabelClusterCredentialType the label on the cluster secret marking the
+	// type of the credential
+	LabelClusterCredentialType = "cluster.core.oam.dev/cluster-credential-type"
+
+	// CredentialTypeX509Certificate the credential of client certificate
+	CredentialTypeX509Certificate = "X509Certificate"
+	// CredentialTypeServiceAccountToken the credential of bearer token
+	CredentialTypeServiceAccountToken = "ServiceAccountToken"
+
+	// DefaultCredentialRenewBefore the default time before expiry to renew the
+	// credential
+	DefaultCredentialRenewBefore = 7 * 24 * time.Hour
+	// DefaultCredentialCheckInterval the default interval for checking the
+	// credential expiry
+	DefaultCredentialCheckInterval = time.Hour
+)
+
+var (
+	// clusterCredentialExpiryGauge records the expiry time of the cluster
+	// credentials
+	clusterCredentialExpiryGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubevela_cluster_credential_expiry_timestamp_seconds",
+		Help: "the unix timestamp when the credential of the managed cluster expires",
+	}, []string{"cluster", "type"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(clusterCredentialExpiryGauge)
+}
+
+// CredentialExpiry the expiry of the credential for one managed cluster
+type CredentialExpiry struct {
+	Cluster string
+	Type    string
+	// ExpiresAt the time when the credential expires. It is zero if the
+	// credential never expires.
+	ExpiresAt time.Time
+}
+
+// ExpiresWithin check if the credential expires within d from now
+func (e CredentialExpiry) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return !e.ExpiresAt.IsZero() && e.ExpiresAt.Sub(now) <= d
+}
+
+// GetCredentialExpiry reads the expiry of the credential in the cluster
+// secret. The expiry of certificates is the NotAfter of the leaf certificate
+// and the expiry of tokens is the exp claim of the JWT.
+func GetCredentialExpiry(secret *corev1.Secret) (*CredentialExpiry, error) {
+	expiry := &CredentialExpiry{Cluster: secret.Name, Type: secret.Labels[LabelClusterCredentialType]}
+	switch expiry.Type {
+	case CredentialTypeX509Certificate:
+		block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+		if block == nil {
+			return nil, fmt.Errorf("no certificate found in cluster secret %s", secret.Name)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in cluster secret %s: %w", secret.Name, err)
+		}
+		expiry.ExpiresAt = cert.NotAfter
+	case CredentialTypeServiceAccountToken:
+		exp, err := getTokenExpiry(string(secret.Data[corev1.ServiceAccountTokenKey]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid token in cluster secret %s: %w", secret.Name, err)
+		}
+		expiry.ExpiresAt = exp
+	default:
+		return nil, fmt.Errorf("unknown credential type %q for cluster secret %s", expiry.Type, secret.Name)
+	}
+	return expiry, nil
+}
+
+// getTokenExpiry reads the exp claim of the JWT without verifying it. Tokens
+// not in the JWT format or without exp claim never expire.
+func getTokenExpiry(token string) (time.Time, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return time.Time{}, nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, err
+	}
+	claims := struct {
+		Exp *int64 `json:"exp"`
+	}{}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, err
+	}
+	if claims.Exp == nil {
+		return time.Time{}, nil
+	}
+	return time.Unix(*claims.Exp, 0).UTC(), nil
+}
+
+// RenewalHook renews the credential of the cluster that is going to expire,
+// such as rotating the secret or re-running the bootstrap
+type RenewalHook interface {
+	Renew(ctx context.Context, expiry CredentialExpiry) error
+}
+
+// RenewalHookFunc the function implementing RenewalHook
+type RenewalHookFunc func(ctx context.Context, expiry CredentialExpiry) error
+
+// Renew .
+func (fn RenewalHookFunc) Renew(ctx context.Context, expiry CredentialExpiry) error {
+	return fn(ctx, expiry)
+}
+
+// CredentialMonitor tracks the expiry of the managed cluster credentials. It
+// exports the expiry as metrics, warns and invokes the renewal hooks for the
+// credentials going to expire.
+type CredentialMonitor struct {
+	cli           client.Client
+	namespace     string
+	renewBefore   time.Duration
+	checkInterval time.Duration
+	hooks         []RenewalHook
+
+	mu sync.Mutex
+	// reported the credential type of the clusters with the expiry exported
+	// in the gauge
+	reported map[string]string
+}
+
+// CredentialMonitorOption the option for creating CredentialMonitor
+type CredentialMonitorOption interface {
+	ApplyToCredentialMonitor(*CredentialMonitor)
+}
+
+// WithClusterSecretNamespace set the namespace of the cluster secrets
+type WithClusterSecretNamespace string
+
+// ApplyToCredentialMonitor .
+func (op WithClusterSecretNamespace) ApplyToCredentialMonitor(m *CredentialMonitor) {
+	m.namespace = string(op)
+}
+
+// WithRenewBefore set the time before expiry to warn and renew the credential
+type WithRenewBefore time.Duration
+
+// ApplyToCredentialMonitor .
+func (op WithRenewBefore) ApplyToCredentialMonitor(m *CredentialMonitor) {
+	m.renewBefore = time.Duration(op)
+}
+
+// WithCheckInterval set the interval for checking the credential expiry.
+// Non-positive intervals fall back to DefaultCredentialCheckInterval.
+type WithCheckInterval time.Duration
+
+// ApplyToCredentialMonitor .
+func (op WithCheckInterval) ApplyToCredentialMonitor(m *CredentialMonitor) {
+	m.checkInterval = time.Duration(op)
+	if m.checkInterval <= 0 {
+		m.checkInterval = DefaultCredentialCheckInterval
+	}
+}
+
+// WithRenewalHook add the hook for renewing the credentials going to expire.
+// Hooks are invoked in order until one fails.
+type WithRenewalHook struct {
+	RenewalHook
+}
+
+// ApplyToCredentialMonitor .
+func (op WithRenewalHook) ApplyToCredentialMonitor(m *CredentialMonitor) {
+	m.hooks = append(m.hooks, op.RenewalHook)
+}
+
+// NewCredentialMonitor create a CredentialMonitor reading the cluster secrets
+// through the client
+func NewCredentialMonitor(cli client.Client, options ...CredentialMonitorOption) *CredentialMonitor {
+	m := &CredentialMonitor{
+		cli:           cli,
+		namespace:     DefaultClusterSecretNamespace,
+		renewBefore:   DefaultCredentialRenewBefore,
+		checkInterval: DefaultCredentialCheckInterval,
+	}
+	for _, op := range options {
+		op.ApplyToCredentialMonitor(m)
+	}
+	return m
+}
+
+// Check reads the expiry of all the cluster credentials and renews the ones
+// going to expire. The expiry of all the readable credentials are returned
+// along with the aggregated errors.
+func (m *CredentialMonitor) Check(ctx context.Context) ([]CredentialExpiry, error) {
+	secrets := &corev1.SecretList{}
+	if err := m.cli.List(ctx, secrets, client.InNamespace(m.namespace), client.HasLabels{LabelClusterCredentialType}); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var expiries []CredentialExpiry
+	var errs []error
+	var unreadable []string
+	reported := map[string]string{}
+	for i := range secrets.Items {
+		expiry, err := GetCredentialExpiry(&secrets.Items[i])
+		if err != nil {
+			errs = append(errs, err)
+			unreadable = append(unreadable, secrets.Items[i].Name)
+			continue
+		}
+		expiries = append(expiries, *expiry)
+		if expiry.ExpiresAt.IsZero() {
+			continue
+		}
+		reported[expiry.Cluster] = expiry.Type
+		clusterCredentialExpiryGauge.WithLabelValues(expiry.Cluster, expiry.Type).Set(float64(expiry.ExpiresAt.Unix()))
+		if !expiry.ExpiresWithin(now, m.renewBefore) {
+			continue
+		}
+		klog.Warningf("credential of cluster %s expires at %s", expiry.Cluster, expiry.ExpiresAt.Format(time.RFC3339))
+		for _, hook := range m.hooks {
+			if err = hook.Renew(ctx, *expiry); err != nil {
+				errs = append(errs, fmt.Errorf("failed to renew credential of cluster %s: %w", expiry.Cluster, err))
+				break
+			}
+		}
+	}
+	m.updateGauge(reported, unreadable)
+	return expiries, utilerrors.NewAggregate(errs)
+}
+
+// updateGauge removes the expiry of the clusters no longer reported. The
+// last expiry of the unreadable credentials is kept.
+func (m *CredentialMonitor) updateGauge(reported map[string]string, unreadable []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, cluster := range unreadable {
+		if credentialType, found := m.reported[cluster]; found {
+			reported[cluster] = credentialType
+		}
+	}
+	for cluster, credentialType := range m.reported {
+		if reported[cluster] != credentialType {
+			clusterCredentialExpiryGauge.DeleteLabelValues(cluster, credentialType)
+		}
+	}
+	m.reported = reported
+}
+
+// Start checks the credentials periodically until the context is done. It
+// can be added to the controller manager as a Runnable.
+func (m *CredentialMonitor) Start(ctx context.Context) error {
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := m.Check(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			klog.Errorf("failed to check cluster credentials: %s", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
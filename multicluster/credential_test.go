@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newClusterSecret(name string, credentialType string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: DefaultClusterSecretNamespace,
+			Name:      name,
+			Labels:    map[string]string{LabelClusterCredentialType: credentialType},
+		},
+		Data: data,
+	}
+}
+
+func newTestCertificate(t *testing.T, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "vela"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newTestToken(claims string) []byte {
+	enc := base64.RawURLEncoding.EncodeToString
+	return []byte(enc([]byte(`{"alg":"RS256"}`)) + "." + enc([]byte(claims)) + ".sig")
+}
+
+func TestCredentialMonitor(t *testing.T) {
+	r := require.New(t)
+	certExpiry := time.Now().Add(24 * time.Hour).Truncate(time.Second).UTC()
+	tokenExpiry := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second).UTC()
+	cli := fake.NewClientBuilder().WithObjects(
+		newClusterSecret("cert", CredentialTypeX509Certificate, map[string][]byte{corev1.TLSCertKey: newTestCertificate(t, certExpiry)}),
+		newClusterSecret("token", CredentialTypeServiceAccountToken, map[string][]byte{
+			corev1.ServiceAccountTokenKey: newTestToken(fmt.Sprintf(`{"exp":%d}`, tokenExpiry.Unix()))}),
+		newClusterSecret("legacy-token", CredentialTypeServiceAccountToken, map[string][]byte{corev1.ServiceAccountTokenKey: newTestToken(`{}`)}),
+		newClusterSecret("bad", CredentialTypeX509Certificate, map[string][]byte{corev1.TLSCertKey: []byte("bad")}),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: DefaultClusterSecretNamespace, Name: "other"}},
+	).Build()
+
+	var renewed []string
+	m := NewCredentialMonitor(cli, WithRenewBefore(7*24*time.Hour), WithRenewalHook{RenewalHookFunc(func(ctx context.Context, expiry CredentialExpiry) error {
+		renewed = append(renewed, expiry.Cluster)
+		return nil
+	})})
+	expiries, err := m.Check(context.Background())
+	r.Error(err)
+	r.Contains(err.Error(), "bad")
+	r.Equal([]CredentialExpiry{
+		{Cluster: "cert", Type: CredentialTypeX509Certificate, ExpiresAt: certExpiry},
+		{Cluster: "legacy-token", Type: CredentialTypeServiceAccountToken},
+		{Cluster: "token", Type: CredentialTypeServiceAccountToken, ExpiresAt: tokenExpiry},
+	}, expiries)
+	r.Equal([]string{"cert"}, renewed)
+	r.Equal(float64(certExpiry.Unix()), testutil.ToFloat64(clusterCredentialExpiryGauge.WithLabelValues("cert", CredentialTypeX509Certificate)))
+	r.Equal(2, testutil.CollectAndCount(clusterCredentialExpiryGauge))
+
+	// the expiry of removed clusters is deleted
+	r.NoError(cli.Delete(context.Background(), newClusterSecret("cert", CredentialTypeX509Certificate, nil)))
+	_, err = m.Check(context.Background())
+	r.Error(err)
+	r.Equal(1, testutil.CollectAndCount(clusterCredentialExpiryGauge))
+	r.Equal(float64(tokenExpiry.Unix()), testutil.ToFloat64(clusterCredentialExpiryGauge.WithLabelValues("token", CredentialTypeServiceAccountToken)))
+
+	m = NewCredentialMonitor(cli, WithClusterSecretNamespace("default"), WithCheckInterval(time.Millisecond),
+		WithRenewalHook{RenewalHookFunc(func(ctx context.Context, expiry CredentialExpiry) error {
+			return fmt.Errorf("renew failed")
+		})})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r.NoError(m.Start(ctx))
+
+	// non-positive intervals fall back to the default instead of panicking
+	for _, interval := range []time.Duration{0, -time.Second} {
+		m = NewCredentialMonitor(cli, WithCheckInterval(interval))
+		r.Equal(DefaultCredentialCheckInterval, m.checkInterval)
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		r.NoError(m.Start(ctx))
+		cancel()
+	}
+}
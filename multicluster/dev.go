@@ -0,0 +1,323 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DevClientOptions the options for creating the multi-cluster client in
+// developer mode
+type DevClientOptions struct {
+	client.Options
+	// Kubeconfig the path of the kubeconfig file which holds the Contexts. If
+	// empty, the default loading rules of kubeconfig will be used.
+	Kubeconfig string
+	// Contexts maps cluster names to kubeconfig contexts. Requests to these
+	// clusters will be sent with the config of the mapped context.
+	Contexts map[string]string
+}
+
+const (
+	// LabelDevCluster the label on the local namespace recording the emulated
+	// cluster in developer mode
+	LabelDevCluster = "cluster.oam.dev/dev-cluster"
+	// LabelDevNamespace the label on the local namespace recording the
+	// emulated namespace in developer mode
+	LabelDevNamespace = "cluster.oam.dev/dev-namespace"
+)
+
+// DevNamespace returns the namespace in the local cluster which emulates the
+// namespace of the given cluster in developer mode
+func DevNamespace(cluster string, namespace string) string {
+	return cluster + "-" + namespace
+}
+
+// DevNamespaceConflictError the error for the local namespace which does not
+// emulate the expected cluster and namespace, such as "x-ns" of cluster "c"
+// and "ns" of cluster "c-x", or a real namespace named like an emulated one
+type DevNamespaceConflictError struct {
+	Cluster   string
+	Namespace string
+}
+
+// Error .
+func (e *DevNamespaceConflictError) Error() string {
+	return fmt.Sprintf("namespace %s is not managed for namespace %s of cluster %s in developer mode",
+		DevNamespace(e.Cluster, e.Namespace), e.Namespace, e.Cluster)
+}
+
+// NewDevClient create a multi-cluster client for local development without
+// provisioning real managed clusters. Requests to clusters listed in
+// options.Contexts are sent to the corresponding kubeconfig contexts (for
+// example, multiple kind clusters). Requests to other non-local clusters are
+// emulated in the local cluster, where namespace "ns" of cluster "c" is
+// mapped to namespace DevNamespace("c", "ns"). The mapped namespaces are
+// created on demand with the LabelDevCluster and LabelDevNamespace labels, and
+// local namespaces without matching labels are never used for emulation.
+// Cluster-scoped resources are shared among the emulated clusters.
+func NewDevClient(config *rest.Config, options DevClientOptions) (client.Client, error) {
+	base, err := client.New(config, options.Options)
+	if err != nil {
+		return nil, err
+	}
+	c := &devClient{base: base, clusters: map[string]client.Client{}}
+	for cluster, kubeContext := range options.Contexts {
+		cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: options.Kubeconfig},
+			&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+		).ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		if c.clusters[cluster], err = client.New(cfg, options.Options); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// devClient dispatches requests to the clients of kubeconfig contexts or
+// emulates clusters by namespaces in the local cluster
+type devClient struct {
+	base     client.Client
+	clusters map[string]client.Client
+	// managed caches the cluster and the namespace emulated by the verified
+	// local namespaces
+	managed sync.Map
+}
+
+var _ client.Client = &devClient{}
+var _ client.StatusWriter = &devStatusWriter{}
+
+// route returns the client for the request. If the cluster is emulated by
+// namespaces, the cluster name is returned as well.
+func (c *devClient) route(ctx context.Context) (client.Client, string) {
+	cluster, _ := ClusterFrom(ctx)
+	if IsLocal(cluster) {
+		return c.base, ""
+	}
+	if cli, found := c.clusters[cluster]; found {
+		return cli, ""
+	}
+	return c.base, cluster
+}
+
+// devNamespaceOwner the cluster and the namespace emulated by the local
+// namespace
+type devNamespaceOwner struct {
+	cluster   string
+	namespace string
+}
+
+// resolveNamespace returns the local namespace emulating the namespace of the
+// cluster. The local namespace is labeled with the cluster and the namespace
+// it emulates, and namespaces with other labels are rejected. If create is
+// true, the local namespace is created when missing.
+func (c *devClient) resolveNamespace(ctx context.Context, cluster string, namespace string, create bool) (string, error) {
+	name := DevNamespace(cluster, namespace)
+	owner := devNamespaceOwner{cluster: cluster, namespace: namespace}
+	if cached, found := c.managed.Load(name); found {
+		if cached != owner {
+			return "", &DevNamespaceConflictError{Cluster: cluster, Namespace: namespace}
+		}
+		return name, nil
+	}
+	ns := &corev1.Namespace{}
+	err := c.base.Get(ctx, client.ObjectKey{Name: name}, ns)
+	switch {
+	case kerrors.IsNotFound(err) && create:
+		ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{
+			LabelDevCluster:   cluster,
+			LabelDevNamespace: namespace,
+		}}}
+		if err = c.base.Create(ctx, ns); err != nil && !kerrors.IsAlreadyExists(err) {
+			return "", err
+		}
+		if kerrors.IsAlreadyExists(err) {
+			return c.resolveNamespace(ctx, cluster, namespace, false)
+		}
+	case kerrors.IsNotFound(err):
+		// nothing exists in the namespace yet
+		return name, nil
+	case err != nil:
+		return "", err
+	case ns.Labels[LabelDevCluster] != cluster || ns.Labels[LabelDevNamespace] != namespace:
+		return "", &DevNamespaceConflictError{Cluster: cluster, Namespace: namespace}
+	}
+	c.managed.Store(name, owner)
+	return name, nil
+}
+
+// withObject maps the namespace of the object into the emulated one while
+// calling fn, and restores it afterwards
+func (c *devClient) withObject(ctx context.Context, cluster string, obj client.Object, create bool, fn func() error) error {
+	namespace := obj.GetNamespace()
+	if cluster == "" || namespace == "" {
+		return fn()
+	}
+	name, err := c.resolveNamespace(ctx, cluster, namespace, create)
+	if err != nil {
+		return err
+	}
+	obj.SetNamespace(name)
+	defer obj.SetNamespace(namespace)
+	return fn()
+}
+
+// restoreList recovers the namespaces of the listed items. Items outside the
+// namespaces emulating the cluster are removed from the list.
+func (c *devClient) restoreList(ctx context.Context, cluster string, list client.ObjectList) error {
+	namespaces := &corev1.NamespaceList{}
+	if err := c.base.List(ctx, namespaces, client.MatchingLabels{LabelDevCluster: cluster}); err != nil {
+		return err
+	}
+	emulated := map[string]string{}
+	for _, ns := range namespaces.Items {
+		if ns.Name == DevNamespace(cluster, ns.Labels[LabelDevNamespace]) {
+			emulated[ns.Name] = ns.Labels[LabelDevNamespace]
+		}
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	var filtered []runtime.Object
+	for _, item := range items {
+		o, err := meta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		if namespace := o.GetNamespace(); namespace != "" {
+			original, found := emulated[namespace]
+			if !found {
+				continue
+			}
+			o.SetNamespace(original)
+		}
+		filtered = append(filtered, item)
+	}
+	return meta.SetList(list, filtered)
+}
+
+func (c *devClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	cli, cluster := c.route(ctx)
+	if cluster == "" || key.Namespace == "" {
+		return cli.Get(ctx, key, obj)
+	}
+	namespace := key.Namespace
+	name, err := c.resolveNamespace(ctx, cluster, namespace, false)
+	if err != nil {
+		return err
+	}
+	key.Namespace = name
+	defer obj.SetNamespace(namespace)
+	return cli.Get(ctx, key, obj)
+}
+
+func (c *devClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	cli, cluster := c.route(ctx)
+	if cluster == "" {
+		return cli.List(ctx, list, opts...)
+	}
+	listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+	if listOpts.Namespace != "" {
+		name, err := c.resolveNamespace(ctx, cluster, listOpts.Namespace, false)
+		if err != nil {
+			return err
+		}
+		listOpts.Namespace = name
+	}
+	if err := cli.List(ctx, list, listOpts); err != nil {
+		return err
+	}
+	return c.restoreList(ctx, cluster, list)
+}
+
+func (c *devClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	cli, cluster := c.route(ctx)
+	return c.withObject(ctx, cluster, obj, true, func() error { return cli.Create(ctx, obj, opts...) })
+}
+
+func (c *devClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	cli, cluster := c.route(ctx)
+	return c.withObject(ctx, cluster, obj, false, func() error { return cli.Delete(ctx, obj, opts...) })
+}
+
+func (c *devClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	cli, cluster := c.route(ctx)
+	return c.withObject(ctx, cluster, obj, false, func() error { return cli.Update(ctx, obj, opts...) })
+}
+
+func (c *devClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	cli, cluster := c.route(ctx)
+	return c.withObject(ctx, cluster, obj, true, func() error { return cli.Patch(ctx, obj, patch, opts...) })
+}
+
+func (c *devClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	cli, cluster := c.route(ctx)
+	if cluster == "" {
+		return cli.DeleteAllOf(ctx, obj, opts...)
+	}
+	deleteOpts := (&client.DeleteAllOfOptions{}).ApplyOptions(opts)
+	if deleteOpts.Namespace != "" {
+		name, err := c.resolveNamespace(ctx, cluster, deleteOpts.Namespace, false)
+		if err != nil {
+			return err
+		}
+		deleteOpts.Namespace = name
+	}
+	return cli.DeleteAllOf(ctx, obj, deleteOpts)
+}
+
+func (c *devClient) Status() client.StatusWriter {
+	return &devStatusWriter{client: c}
+}
+
+func (c *devClient) Scheme() *runtime.Scheme {
+	return c.base.Scheme()
+}
+
+func (c *devClient) RESTMapper() meta.RESTMapper {
+	return c.base.RESTMapper()
+}
+
+// devStatusWriter the status writer for devClient
+type devStatusWriter struct {
+	client *devClient
+}
+
+func (w *devStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	cli, cluster := w.client.route(ctx)
+	return w.client.withObject(ctx, cluster, obj, false, func() error { return cli.Status().Update(ctx, obj, opts...) })
+}
+
+func (w *devStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	cli, cluster := w.client.route(ctx)
+	return w.client.withObject(ctx, cluster, obj, false, func() error { return cli.Status().Patch(ctx, obj, patch, opts...) })
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDevClient(t *testing.T) {
+	base := fake.NewClientBuilder().Build()
+	context1 := fake.NewClientBuilder().Build()
+	c := &devClient{base: base, clusters: map[string]client.Client{"kind-1": context1}}
+	ctx := WithCluster(context.Background(), "c1")
+	key := client.ObjectKey{Namespace: "default", Name: "example"}
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+	require.NoError(t, c.Create(ctx, cm))
+	require.Equal(t, "default", cm.Namespace)
+	require.NoError(t, base.Get(context.Background(), client.ObjectKey{Namespace: "c1-default", Name: key.Name}, &corev1.ConfigMap{}))
+	ns := &corev1.Namespace{}
+	require.NoError(t, base.Get(context.Background(), client.ObjectKey{Name: "c1-default"}, ns))
+	require.Equal(t, map[string]string{LabelDevCluster: "c1", LabelDevNamespace: "default"}, ns.Labels)
+	require.True(t, kerrors.IsNotFound(c.Get(context.Background(), key, &corev1.ConfigMap{})))
+
+	cm = &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, key, cm))
+	require.Equal(t, "default", cm.Namespace)
+	cm.Data = map[string]string{"k": "v"}
+	require.NoError(t, c.Update(ctx, cm))
+	require.NoError(t, c.Patch(ctx, cm, client.Merge))
+
+	require.NoError(t, c.Create(context.Background(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: "local"}}))
+	require.NoError(t, c.Create(WithCluster(context.Background(), "c2"), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: "c2"}}))
+	cms := &corev1.ConfigMapList{}
+	require.NoError(t, c.List(ctx, cms))
+	require.Equal(t, 1, len(cms.Items))
+	require.Equal(t, "default", cms.Items[0].Namespace)
+	require.Equal(t, "v", cms.Items[0].Data["k"])
+	require.NoError(t, c.List(ctx, cms, client.InNamespace("default")))
+	require.Equal(t, 1, len(cms.Items))
+
+	require.NoError(t, c.DeleteAllOf(ctx, &corev1.ConfigMap{}, client.InNamespace("default")))
+	require.NoError(t, c.List(ctx, cms))
+	require.Empty(t, cms.Items)
+	require.NoError(t, c.List(context.Background(), cms, client.InNamespace("default")))
+	require.Equal(t, 1, len(cms.Items))
+
+	ctx = WithCluster(context.Background(), "kind-1")
+	require.NoError(t, c.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}))
+	require.NoError(t, context1.Get(context.Background(), key, &corev1.ConfigMap{}))
+	require.NoError(t, c.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}))
+	require.True(t, kerrors.IsNotFound(context1.Get(context.Background(), key, &corev1.ConfigMap{})))
+}
+
+func TestDevClientPrefixClusters(t *testing.T) {
+	r := require.New(t)
+	base := fake.NewClientBuilder().WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "c-real"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "c-real", Name: "local"}},
+	).Build()
+	c := &devClient{base: base, clusters: map[string]client.Client{}}
+	c1, c2 := WithCluster(context.Background(), "c"), WithCluster(context.Background(), "c-x")
+
+	r.NoError(c.Create(c1, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "c"}}))
+	r.NoError(c.Create(c2, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "c-x"}}))
+
+	cms := &corev1.ConfigMapList{}
+	r.NoError(c.List(c1, cms))
+	r.Equal(1, len(cms.Items))
+	r.Equal("c", cms.Items[0].Name)
+	r.Equal("default", cms.Items[0].Namespace)
+	r.NoError(c.List(c2, cms))
+	r.Equal(1, len(cms.Items))
+	r.Equal("c-x", cms.Items[0].Name)
+
+	// "x-default" of cluster "c" is mapped to the same namespace as "default"
+	// of cluster "c-x"
+	conflictErr := &DevNamespaceConflictError{}
+	r.ErrorAs(c.Create(c1, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "x-default", Name: "c"}}), &conflictErr)
+	r.ErrorAs(c.Get(c1, client.ObjectKey{Namespace: "x-default", Name: "c-x"}, &corev1.ConfigMap{}), &conflictErr)
+	// real local namespaces are not emulated namespaces
+	r.ErrorAs(c.Get(c1, client.ObjectKey{Namespace: "real", Name: "local"}, &corev1.ConfigMap{}), &conflictErr)
+	r.ErrorAs(c.List(c1, cms, client.InNamespace("real")), &conflictErr)
+	r.True(kerrors.IsNotFound(c.Get(c1, client.ObjectKey{Namespace: "missing", Name: "c"}, &corev1.ConfigMap{})))
+}
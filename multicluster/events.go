@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterEvent is the event tagged with the cluster it comes from
+type ClusterEvent struct {
+	corev1.Event
+	Cluster string
+	// Timestamp the normalized time of the last occurrence of the event
+	Timestamp time.Time
+}
+
+// ListEventsAcrossClusters collects the events of the involved objects from
+// all the clusters and returns them in time order. Involved objects with UID
+// are matched by UID, otherwise by kind, namespace and name. Events from the
+// clusters that fail to respond are skipped and the errors are aggregated.
+func ListEventsAcrossClusters(ctx context.Context, cli client.Client, clusters []string, involvedObjects []corev1.ObjectReference) ([]ClusterEvent, error) {
+	namespaces := map[string][]corev1.ObjectReference{}
+	for _, ref := range involvedObjects {
+		namespaces[ref.Namespace] = append(namespaces[ref.Namespace], ref)
+	}
+	var (
+		mu     sync.Mutex
+		events []ClusterEvent
+		errs   []error
+		wg     sync.WaitGroup
+	)
+	for _, cluster := range clusters {
+		wg.Add(1)
+		go func(cluster string) {
+			defer wg.Done()
+			_events, err := listClusterEvents(WithCluster(ctx, cluster), cluster, cli, namespaces)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to list events in cluster %s: %w", cluster, err))
+			}
+			events = append(events, _events...)
+		}(cluster)
+	}
+	wg.Wait()
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Timestamp.Equal(events[j].Timestamp) {
+			return events[i].Timestamp.Before(events[j].Timestamp)
+		}
+		if events[i].Cluster != events[j].Cluster {
+			return events[i].Cluster < events[j].Cluster
+		}
+		return events[i].Name < events[j].Name
+	})
+	return events, utilerrors.NewAggregate(errs)
+}
+
+func listClusterEvents(ctx context.Context, cluster string, cli client.Client, namespaces map[string][]corev1.ObjectReference) ([]ClusterEvent, error) {
+	var events []ClusterEvent
+	for namespace, refs := range namespaces {
+		list := &corev1.EventList{}
+		if err := cli.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return events, err
+		}
+		for _, event := range list.Items {
+			for _, ref := range refs {
+				if isEventInvolved(event.InvolvedObject, ref) {
+					events = append(events, ClusterEvent{Event: event, Cluster: cluster, Timestamp: getEventTimestamp(event)})
+					break
+				}
+			}
+		}
+	}
+	return events, nil
+}
+
+func isEventInvolved(involved corev1.ObjectReference, ref corev1.ObjectReference) bool {
+	if ref.UID != "" {
+		return involved.UID == ref.UID
+	}
+	return involved.Kind == ref.Kind && involved.Namespace == ref.Namespace && involved.Name == ref.Name
+}
+
+// getEventTimestamp returns the time of the last occurrence. Events recorded
+// by the events.k8s.io API only carry the event time or the series.
+func getEventTimestamp(event corev1.Event) time.Time {
+	switch {
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
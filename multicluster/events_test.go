@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestListEventsAcrossClusters(t *testing.T) {
+	r := require.New(t)
+	base := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	newEvent := func(name string, involved corev1.ObjectReference, offset time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: name},
+			InvolvedObject: involved,
+			LastTimestamp:  metav1.NewTime(base.Add(offset)),
+		}
+	}
+	deploy := corev1.ObjectReference{Kind: "Deployment", Namespace: "default", Name: "app"}
+	pod := corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "app-xyz", UID: "pod-uid"}
+	series := newEvent("series", pod, 0)
+	series.LastTimestamp = metav1.Time{}
+	series.Series = &corev1.EventSeries{Count: 3, LastObservedTime: metav1.NewMicroTime(base.Add(3 * time.Minute))}
+	cli := &clusterRouter{clusters: map[string]client.Client{
+		"c1": fake.NewClientBuilder().WithObjects(
+			newEvent("scaled", deploy, time.Minute),
+			newEvent("other", corev1.ObjectReference{Kind: "Deployment", Namespace: "default", Name: "other"}, 0),
+		).Build(),
+		"c2": fake.NewClientBuilder().WithObjects(
+			newEvent("pulled", pod, 2*time.Minute),
+			newEvent("reused-name", corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "app-xyz", UID: "old-uid"}, 0),
+			series,
+		).Build(),
+	}}
+
+	events, err := ListEventsAcrossClusters(context.Background(), cli, []string{"c1", "c2", "unknown"}, []corev1.ObjectReference{deploy, pod})
+	r.Error(err)
+	r.Contains(err.Error(), "unknown")
+	r.Equal(3, len(events))
+	r.Equal("scaled", events[0].Name)
+	r.Equal("c1", events[0].Cluster)
+	r.Equal("pulled", events[1].Name)
+	r.Equal("c2", events[1].Cluster)
+	r.Equal("series", events[2].Name)
+	r.Equal(base.Add(3*time.Minute), events[2].Timestamp.UTC())
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+// CleanupAction the action to clean up the reference to the removed cluster
+type CleanupAction string
+
+const (
+	// CleanupActionDelete deletes the object referencing the cluster
+	CleanupActionDelete CleanupAction = "Delete"
+	// CleanupActionRemoveReference removes the cluster from the label or
+	// annotation of the object and keeps the object
+	CleanupActionRemoveReference CleanupAction = "RemoveReference"
+)
+
+// ClusterReferenceRule describes how the hub objects of the kind reference
+// managed clusters. The object references the cluster if the label equals to
+// the cluster name, or the annotation contains the cluster name in its
+// comma-separated list, or Match returns true.
+type ClusterReferenceRule struct {
+	GroupVersionKind schema.GroupVersionKind
+	// Namespace restricts the objects to the namespace if set
+	Namespace     string
+	LabelKey      string
+	AnnotationKey string
+	Match         func(obj *unstructured.Unstructured, cluster string) bool
+	// Action the action for the matched objects, defaults to
+	// CleanupActionDelete. Objects matched by Match are always deleted.
+	Action CleanupAction
+}
+
+// CleanupItem one object to be cleaned up
+type CleanupItem struct {
+	GroupVersionKind schema.GroupVersionKind
+	Namespace        string
+	Name             string
+	// ResourceVersion the version of the object when planned. The object is
+	// not changed by Execute if it is modified after the plan is made.
+	ResourceVersion string
+	Action          CleanupAction
+	// Labels and Annotations the keys to remove or update for
+	// CleanupActionRemoveReference. Empty value means the key is removed.
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// String .
+func (item CleanupItem) String() string {
+	name := item.Name
+	if item.Namespace != "" {
+		name = item.Namespace + "/" + name
+	}
+	return fmt.Sprintf("%s %s %s", item.Action, item.GroupVersionKind.Kind, name)
+}
+
+// CleanupPlan the plan to clean up the hub objects referencing the removed
+// cluster
+type CleanupPlan struct {
+	Cluster string
+	Items   []CleanupItem
+}
+
+// PlanClusterCleanup enumerates the hub objects referencing the cluster by the
+// rules and produces the plan to clean them up. Kinds not installed in the hub
+// are skipped.
+func PlanClusterCleanup(ctx context.Context, cli client.Client, cluster string, rules []ClusterReferenceRule) (*CleanupPlan, error) {
+	ctx = WithCluster(ctx, Local)
+	plan := &CleanupPlan{Cluster: cluster}
+	for _, rule := range rules {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(rule.GroupVersionKind.GroupVersion().WithKind(rule.GroupVersionKind.Kind + "List"))
+		opts := []client.ListOption{client.InNamespace(rule.Namespace)}
+		if rule.LabelKey != "" && rule.AnnotationKey == "" && rule.Match == nil {
+			opts = append(opts, client.MatchingLabels{rule.LabelKey: cluster})
+		}
+		if err := cli.List(ctx, list, opts...); err != nil {
+			if kerrors.IsNotFound(err) || k8s.IsCRDNotInstalled(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to list %s: %w", rule.GroupVersionKind.Kind, err)
+		}
+		for i := range list.Items {
+			if item, matched := planCleanupItem(&list.Items[i], cluster, rule); matched {
+				plan.Items = append(plan.Items, item)
+			}
+		}
+	}
+	sort.SliceStable(plan.Items, func(i, j int) bool { return plan.Items[i].String() < plan.Items[j].String() })
+	return plan, nil
+}
+
+func planCleanupItem(obj *unstructured.Unstructured, cluster string, rule ClusterReferenceRule) (CleanupItem, bool) {
+	item := CleanupItem{
+		GroupVersionKind: rule.GroupVersionKind,
+		Namespace:        obj.GetNamespace(),
+		Name:             obj.GetName(),
+		ResourceVersion:  obj.GetResourceVersion(),
+		Action:           rule.Action,
+	}
+	if item.Action == "" {
+		item.Action = CleanupActionDelete
+	}
+	matched := false
+	if rule.LabelKey != "" && obj.GetLabels()[rule.LabelKey] == cluster {
+		matched = true
+		item.Labels = map[string]string{rule.LabelKey: ""}
+	}
+	if value, found := obj.GetAnnotations()[rule.AnnotationKey]; found && rule.AnnotationKey != "" {
+		var left []string
+		referenced := false
+		for _, c := range strings.Split(value, ",") {
+			switch c = strings.TrimSpace(c); c {
+			case cluster:
+				referenced = true
+			case "":
+			default:
+				left = append(left, c)
+			}
+		}
+		if referenced {
+			matched = true
+			item.Annotations = map[string]string{rule.AnnotationKey: strings.Join(left, ",")}
+		}
+	}
+	if !matched && rule.Match != nil && rule.Match(obj, cluster) {
+		matched = true
+		item.Action = CleanupActionDelete
+	}
+	return item, matched
+}
+
+// Execute runs the plan against the hub cluster. Objects already removed are
+// ignored. Objects modified after the plan is made are rejected by the API
+// server with conflicts, and the plan should be made again. All the items are
+// tried and the errors are aggregated.
+func (p *CleanupPlan) Execute(ctx context.Context, cli client.Client, dryRun bool) error {
+	ctx = WithCluster(ctx, Local)
+	var errs []error
+	for _, item := range p.Items {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(item.GroupVersionKind)
+		obj.SetNamespace(item.Namespace)
+		obj.SetName(item.Name)
+		var err error
+		switch item.Action {
+		case CleanupActionDelete:
+			opts := []client.DeleteOption{client.Preconditions{ResourceVersion: &item.ResourceVersion}}
+			if item.ResourceVersion == "" {
+				opts = nil
+			}
+			if dryRun {
+				opts = append(opts, client.DryRunAll)
+			}
+			err = cli.Delete(ctx, obj, opts...)
+		case CleanupActionRemoveReference:
+			var patch []byte
+			if patch, err = item.mergePatch(); err == nil {
+				var opts []client.PatchOption
+				if dryRun {
+					opts = append(opts, client.DryRunAll)
+				}
+				err = cli.Patch(ctx, obj, client.RawPatch(types.MergePatchType, patch), opts...)
+			}
+		default:
+			err = fmt.Errorf("unknown cleanup action %s", item.Action)
+		}
+		if err != nil && !kerrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to %s: %w", strings.ToLower(item.String()), err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// mergePatch returns the merge patch removing the reference, where empty
+// values are patched as null to remove the keys. The resourceVersion is
+// included so that the patch fails with conflict if the object is modified.
+func (item CleanupItem) mergePatch() ([]byte, error) {
+	toPatch := func(m map[string]string) map[string]interface{} {
+		if len(m) == 0 {
+			return nil
+		}
+		patch := map[string]interface{}{}
+		for k, v := range m {
+			if v == "" {
+				patch[k] = nil
+			} else {
+				patch[k] = v
+			}
+		}
+		return patch
+	}
+	metadata := map[string]interface{}{}
+	if item.ResourceVersion != "" {
+		metadata["resourceVersion"] = item.ResourceVersion
+	}
+	if labels := toPatch(item.Labels); labels != nil {
+		metadata["labels"] = labels
+	}
+	if annotations := toPatch(item.Annotations); annotations != nil {
+		metadata["annotations"] = annotations
+	}
+	return json.Marshal(map[string]interface{}{"metadata": metadata})
+}
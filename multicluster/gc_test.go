@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClusterCleanup(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithObjects(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "vela-system", Name: "record-a", Labels: map[string]string{"cluster": "a"}}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "vela-system", Name: "record-b", Labels: map[string]string{"cluster": "b"}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "shared", Annotations: map[string]string{"clusters": "a, b"}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "only-a", Labels: map[string]string{"cluster": "a"},
+			Annotations: map[string]string{"clusters": ""}}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a-proxy"}},
+	).Build()
+	rules := []ClusterReferenceRule{
+		{GroupVersionKind: corev1.SchemeGroupVersion.WithKind("ConfigMap"), LabelKey: "cluster"},
+		{GroupVersionKind: corev1.SchemeGroupVersion.WithKind("Secret"), LabelKey: "cluster", AnnotationKey: "clusters", Action: CleanupActionRemoveReference},
+		{GroupVersionKind: appsv1.SchemeGroupVersion.WithKind("Deployment"), Match: func(obj *unstructured.Unstructured, cluster string) bool {
+			return obj.GetName() == cluster+"-proxy"
+		}},
+	}
+	plan, err := PlanClusterCleanup(ctx, cli, "a", rules)
+	r.NoError(err)
+	var items []string
+	for _, item := range plan.Items {
+		items = append(items, item.String())
+	}
+	r.Equal([]string{
+		"Delete ConfigMap vela-system/record-a",
+		"Delete Deployment default/a-proxy",
+		"RemoveReference Secret default/only-a",
+		"RemoveReference Secret default/shared",
+	}, items)
+
+	r.NoError(plan.Execute(ctx, cli, false))
+	r.True(kerrors.IsNotFound(cli.Get(ctx, client.ObjectKey{Namespace: "vela-system", Name: "record-a"}, &corev1.ConfigMap{})))
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "vela-system", Name: "record-b"}, &corev1.ConfigMap{}))
+	r.True(kerrors.IsNotFound(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "a-proxy"}, &appsv1.Deployment{})))
+	secret := &corev1.Secret{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "shared"}, secret))
+	r.Equal("b", secret.Annotations["clusters"])
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "only-a"}, secret))
+	r.NotContains(secret.Labels, "cluster")
+
+	// executing again ignores the removed objects, and rejects the modified
+	// objects
+	err = plan.Execute(ctx, cli, false)
+	r.Error(err)
+	r.NotContains(err.Error(), "configmap")
+	r.Contains(err.Error(), "secret default/shared")
+
+	// the reference added after planning is kept
+	r.NoError(cli.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "vela-system", Name: "racing",
+		Labels: map[string]string{"cluster": "c"}, Annotations: map[string]string{"clusters": "c"}}}))
+	racingRules := []ClusterReferenceRule{{GroupVersionKind: corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+		AnnotationKey: "clusters", Action: CleanupActionRemoveReference}}
+	plan, err = PlanClusterCleanup(ctx, cli, "c", racingRules)
+	r.NoError(err)
+	r.Len(plan.Items, 1)
+	cm := &corev1.ConfigMap{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "vela-system", Name: "racing"}, cm))
+	cm.Annotations["clusters"] = "c,d"
+	r.NoError(cli.Update(ctx, cm))
+	r.Error(plan.Execute(ctx, cli, false))
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "vela-system", Name: "racing"}, cm))
+	r.Equal("c,d", cm.Annotations["clusters"])
+	plan, err = PlanClusterCleanup(ctx, cli, "c", racingRules)
+	r.NoError(err)
+	r.NoError(plan.Execute(ctx, cli, false))
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "vela-system", Name: "racing"}, cm))
+	r.Equal("d", cm.Annotations["clusters"])
+
+	plan, err = PlanClusterCleanup(ctx, cli, "a", append(rules, ClusterReferenceRule{
+		GroupVersionKind: schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unknown"}, LabelKey: "cluster"}))
+	r.NoError(err)
+	r.Empty(plan.Items)
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	velacapacity "github.com/kubevela/pkg/util/capacity"
+)
+
+// DefaultCapacityCacheTTL the default time for caching the cluster capacity
+const DefaultCapacityCacheTTL = 30 * time.Second
+
+// ClusterCapacity the schedulable resources of a cluster
+type ClusterCapacity struct {
+	Cluster string
+	// Allocatable the allocatable resources of all the schedulable nodes
+	Allocatable corev1.ResourceList
+	// Requested the resources requested by all the scheduled and running pods
+	Requested corev1.ResourceList
+}
+
+// Free returns the allocatable resources not requested yet
+func (c *ClusterCapacity) Free() corev1.ResourceList {
+	free := corev1.ResourceList{}
+	for name, q := range c.Allocatable {
+		q = q.DeepCopy()
+		if requested, found := c.Requested[name]; found {
+			q.Sub(requested)
+		}
+		free[name] = q
+	}
+	return free
+}
+
+// GetClusterCapacity sums up the allocatable resources of the nodes and the
+// requests of the pods in the cluster
+func GetClusterCapacity(ctx context.Context, cli client.Client, cluster string) (*ClusterCapacity, error) {
+	ctx = WithCluster(ctx, cluster)
+	capacity := &ClusterCapacity{Cluster: cluster, Allocatable: corev1.ResourceList{}, Requested: corev1.ResourceList{}}
+	nodes := &corev1.NodeList{}
+	if err := cli.List(ctx, nodes); err != nil {
+		return nil, err
+	}
+	for _, node := range nodes.Items {
+		if !node.Spec.Unschedulable {
+			velacapacity.Add(capacity.Allocatable, node.Status.Allocatable)
+		}
+	}
+	pods := &corev1.PodList{}
+	if err := cli.List(ctx, pods); err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		velacapacity.Add(capacity.Requested, velacapacity.PodRequests(&pod.Spec))
+	}
+	return capacity, nil
+}
+
+// CapacityGetter returns the capacity of the cluster
+type CapacityGetter interface {
+	GetCapacity(ctx context.Context, cluster string) (*ClusterCapacity, error)
+}
+
+// CapacityCache fetches the capacity of clusters and caches it for a while,
+// so that scoring multiple placements does not list all the nodes and pods
+// every time
+type CapacityCache struct {
+	cli client.Client
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]capacityEntry
+}
+
+type capacityEntry struct {
+	capacity  *ClusterCapacity
+	expiredAt time.Time
+}
+
+var _ CapacityGetter = &CapacityCache{}
+
+// NewCapacityCache create a CapacityCache. If ttl is not positive,
+// DefaultCapacityCacheTTL will be used.
+func NewCapacityCache(cli client.Client, ttl time.Duration) *CapacityCache {
+	if ttl <= 0 {
+		ttl = DefaultCapacityCacheTTL
+	}
+	return &CapacityCache{cli: cli, ttl: ttl, entries: map[string]capacityEntry{}}
+}
+
+// GetCapacity returns the cached capacity or fetches it if expired
+func (c *CapacityCache) GetCapacity(ctx context.Context, cluster string) (*ClusterCapacity, error) {
+	c.mu.Lock()
+	entry, found := c.entries[cluster]
+	c.mu.Unlock()
+	if found && time.Now().Before(entry.expiredAt) {
+		return entry.capacity, nil
+	}
+	capacity, err := GetClusterCapacity(ctx, c.cli, cluster)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[cluster] = capacityEntry{capacity: capacity, expiredAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return capacity, nil
+}
+
+// Invalidate drops the cached capacity of the cluster, for example, after
+// workloads are placed into it
+func (c *CapacityCache) Invalidate(cluster string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, cluster)
+}
+
+// Scorer scores the cluster by its capacity. A negative score marks the
+// cluster as infeasible.
+type Scorer func(capacity *ClusterCapacity) float64
+
+// FreeCapacityScorer scores the cluster by the ratio of free resources left
+// after placing the demand, in the range of [0, 100]. The scarcest resource
+// decides the score. Clusters without enough resources for the demand are
+// infeasible. If the demand is empty, cpu and memory are considered.
+func FreeCapacityScorer(demand corev1.ResourceList) Scorer {
+	return func(capacity *ClusterCapacity) float64 {
+		names := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+		if len(demand) > 0 {
+			names = names[:0]
+			for name := range demand {
+				names = append(names, name)
+			}
+		}
+		free := capacity.Free()
+		score := 100.0
+		for _, name := range names {
+			allocatable := capacity.Allocatable[name]
+			left := free[name]
+			if d, found := demand[name]; found {
+				left.Sub(d)
+			}
+			if left.Sign() < 0 || allocatable.Sign() <= 0 {
+				return -1
+			}
+			score = math.Min(score, 100*float64(left.MilliValue())/float64(allocatable.MilliValue()))
+		}
+		return score
+	}
+}
+
+// Placement is a candidate cluster with its score
+type Placement struct {
+	Cluster  string
+	Score    float64
+	Capacity *ClusterCapacity
+}
+
+// RankClusters scores the candidate clusters with the sum of the scorers and
+// returns the feasible ones from the highest score to the lowest. Clusters
+// whose capacity cannot be fetched are skipped.
+func RankClusters(ctx context.Context, getter CapacityGetter, clusters []string, scorers ...Scorer) []Placement {
+	var placements []Placement
+	for _, cluster := range clusters {
+		capacity, err := getter.GetCapacity(ctx, cluster)
+		if err != nil {
+			klog.Warningf("failed to get capacity of cluster %s, skip it for placement: %s", cluster, err.Error())
+			continue
+		}
+		placement := Placement{Cluster: cluster, Capacity: capacity}
+		feasible := true
+		for _, score := range scorers {
+			s := score(capacity)
+			if s < 0 {
+				feasible = false
+				break
+			}
+			placement.Score += s
+		}
+		if feasible {
+			placements = append(placements, placement)
+		}
+	}
+	sort.SliceStable(placements, func(i, j int) bool {
+		if placements[i].Score != placements[j].Score {
+			return placements[i].Score > placements[j].Score
+		}
+		return placements[i].Cluster < placements[j].Cluster
+	})
+	return placements
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// clusterRouter routes requests to the fake client of the cluster in context
+type clusterRouter struct {
+	client.Client
+	clusters map[string]client.Client
+	lists    int64
+}
+
+func (c *clusterRouter) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	cluster, _ := ClusterFrom(ctx)
+	cli, found := c.clusters[cluster]
+	if !found {
+		return fmt.Errorf("cluster %s not found", cluster)
+	}
+	atomic.AddInt64(&c.lists, 1)
+	return cli.List(ctx, list, opts...)
+}
+
+func requestsOf(cpu string, memory string) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+}
+
+func newCapacityCluster(allocatable corev1.ResourceList, requests ...corev1.ResourceList) client.Client {
+	objs := []client.Object{
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}, Status: corev1.NodeStatus{Allocatable: allocatable}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "cordoned"}, Spec: corev1.NodeSpec{Unschedulable: true},
+			Status: corev1.NodeStatus{Allocatable: allocatable}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pending"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Resources: corev1.ResourceRequirements{Requests: allocatable}}}}},
+	}
+	for i, r := range requests {
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("pod-%d", i)},
+			Spec: corev1.PodSpec{
+				NodeName:       "node",
+				Containers:     []corev1.Container{{Resources: corev1.ResourceRequirements{Requests: r}}},
+				InitContainers: []corev1.Container{{Resources: corev1.ResourceRequirements{Requests: requestsOf("10m", "1Mi")}}},
+			},
+		})
+	}
+	return fake.NewClientBuilder().WithObjects(objs...).Build()
+}
+
+func TestRankClusters(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	cli := &clusterRouter{clusters: map[string]client.Client{
+		"idle":  newCapacityCluster(requestsOf("4", "8Gi")),
+		"busy":  newCapacityCluster(requestsOf("4", "8Gi"), requestsOf("3", "2Gi")),
+		"small": newCapacityCluster(requestsOf("1", "1Gi")),
+	}}
+
+	capacity, err := GetClusterCapacity(ctx, cli, "busy")
+	r.NoError(err)
+	r.True(resource.MustParse("4").Equal(capacity.Allocatable[corev1.ResourceCPU]))
+	r.True(resource.MustParse("3").Equal(capacity.Requested[corev1.ResourceCPU]))
+	free := capacity.Free()
+	r.True(resource.MustParse("6Gi").Equal(free[corev1.ResourceMemory]))
+
+	cache := NewCapacityCache(cli, 0)
+	placements := RankClusters(ctx, cache, []string{"small", "busy", "idle", "unknown"}, FreeCapacityScorer(requestsOf("500m", "512Mi")))
+	r.Equal(3, len(placements))
+	r.Equal([]string{"idle", "small", "busy"}, []string{placements[0].Cluster, placements[1].Cluster, placements[2].Cluster})
+	r.InDelta(87.5, placements[0].Score, 0.01)
+
+	placements = RankClusters(ctx, cache, []string{"small", "busy", "idle"}, FreeCapacityScorer(requestsOf("2", "1Gi")))
+	r.Equal(1, len(placements))
+	r.Equal("idle", placements[0].Cluster)
+
+	lists := atomic.LoadInt64(&cli.lists)
+	_ = RankClusters(ctx, cache, []string{"idle"}, FreeCapacityScorer(nil))
+	r.Equal(lists, atomic.LoadInt64(&cli.lists))
+	cache.Invalidate("idle")
+	placements = RankClusters(ctx, cache, []string{"idle"}, FreeCapacityScorer(nil), func(*ClusterCapacity) float64 { return 10 })
+	r.Equal(lists+2, atomic.LoadInt64(&cli.lists))
+	r.InDelta(110, placements[0].Score, 0.01)
+}
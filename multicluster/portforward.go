@@ -0,0 +1,335 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"k8s.io/klog/v2"
+)
+
+// ErrPortForwardIdle the error for port-forward sessions closed for idle
+var ErrPortForwardIdle = errors.New("port-forward session closed for idle timeout")
+
+// errPortForwardStopped the error for reconnection interrupted by Close or the
+// context, which is not reported by Err
+var errPortForwardStopped = errors.New("port-forward session stopped")
+
+// DefaultPortForwardReconnectBackoff the default backoff for re-establishing
+// the lost port-forward connection
+var DefaultPortForwardReconnectBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+	Cap:      10 * time.Second,
+}
+
+type portForwardConfig struct {
+	addresses   []string
+	idleTimeout time.Duration
+	backoff     wait.Backoff
+}
+
+// PortForwardOption the option for port-forward
+type PortForwardOption interface {
+	ApplyToPortForward(*portForwardConfig)
+}
+
+// WithPortForwardAddresses the local addresses to listen on, localhost by
+// default
+type WithPortForwardAddresses []string
+
+// ApplyToPortForward .
+func (op WithPortForwardAddresses) ApplyToPortForward(cfg *portForwardConfig) {
+	cfg.addresses = op
+}
+
+// WithPortForwardIdleTimeout closes the session if no data is transferred
+// within the timeout
+type WithPortForwardIdleTimeout time.Duration
+
+// ApplyToPortForward .
+func (op WithPortForwardIdleTimeout) ApplyToPortForward(cfg *portForwardConfig) {
+	cfg.idleTimeout = time.Duration(op)
+}
+
+// WithPortForwardReconnectBackoff the backoff for re-establishing the lost
+// connection. The session is closed when the steps are exhausted.
+type WithPortForwardReconnectBackoff wait.Backoff
+
+// ApplyToPortForward .
+func (op WithPortForwardReconnectBackoff) ApplyToPortForward(cfg *portForwardConfig) {
+	cfg.backoff = wait.Backoff(op)
+}
+
+// PortForwardSession the port-forward session to the pod
+type PortForwardSession struct {
+	ports      []portforward.ForwardedPort
+	lastActive int64
+	stop       chan struct{}
+	stopOnce   sync.Once
+	done       chan struct{}
+	err        error
+}
+
+// Ports returns the forwarded ports, where the local ports are resolved
+func (s *PortForwardSession) Ports() []portforward.ForwardedPort {
+	return s.ports
+}
+
+// Done returns the channel closed when the session ends
+func (s *PortForwardSession) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason why the session ends. It is nil if the session is
+// still running or closed by Close or the context.
+func (s *PortForwardSession) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close stops the session and waits for the listeners to be released
+func (s *PortForwardSession) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+func (s *PortForwardSession) touch() {
+	atomic.StoreInt64(&s.lastActive, time.Now().UnixNano())
+}
+
+// PortForward forwards the local ports to the pod in the cluster through the
+// multi-cluster transport. The ports are in the format of kubectl port-forward
+// such as "8080:80" or ":80". The connection is re-established automatically
+// with the same local ports when lost, such as the pod restarts. The session
+// ends when the context is cancelled, the session is closed, it is idle for
+// the timeout or the reconnection fails.
+func PortForward(ctx context.Context, config *rest.Config, cluster string, pod types.NamespacedName, ports []string, opts ...PortForwardOption) (*PortForwardSession, error) {
+	cfg := &portForwardConfig{addresses: []string{"localhost"}, backoff: DefaultPortForwardReconnectBackoff}
+	for _, op := range opts {
+		op.ApplyToPortForward(cfg)
+	}
+	rt, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	// keep the path prefix of the host, such as the proxy of the cluster
+	u.Path = path.Join("/", u.Path, portForwardPath(config.APIPath, cluster, pod))
+	session := &PortForwardSession{stop: make(chan struct{}), done: make(chan struct{})}
+	session.touch()
+	dialer := &activityDialer{
+		Dialer: spdy.NewDialer(upgrader, &http.Client{Transport: rt}, http.MethodPost, u),
+		touch:  session.touch,
+	}
+	f, err := startPortForward(dialer, cfg.addresses, ports)
+	if err != nil {
+		return nil, err
+	}
+	if session.ports, err = f.forwarder.GetPorts(); err != nil {
+		f.stop()
+		return nil, err
+	}
+	// keep the local ports when reconnecting
+	resolved := make([]string, 0, len(session.ports))
+	for _, p := range session.ports {
+		resolved = append(resolved, fmt.Sprintf("%d:%d", p.Local, p.Remote))
+	}
+	go session.run(ctx, cfg, dialer, resolved, f)
+	return session, nil
+}
+
+func (s *PortForwardSession) run(ctx context.Context, cfg *portForwardConfig, dialer httpstream.Dialer, ports []string, f *forwarding) {
+	defer close(s.done)
+	var idle <-chan time.Time
+	if cfg.idleTimeout > 0 {
+		interval := cfg.idleTimeout / 10
+		if interval < time.Millisecond {
+			interval = time.Millisecond
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		idle = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			f.stop()
+			return
+		case <-s.stop:
+			f.stop()
+			return
+		case <-idle:
+			if time.Since(time.Unix(0, atomic.LoadInt64(&s.lastActive))) < cfg.idleTimeout {
+				continue
+			}
+			f.stop()
+			s.err = ErrPortForwardIdle
+			return
+		case err := <-f.errCh:
+			klog.V(4).Infof("port-forward connection lost, reconnecting: %v", err)
+			if f, err = s.reconnect(ctx, cfg, dialer, ports); err != nil {
+				if !errors.Is(err, errPortForwardStopped) {
+					s.err = err
+				}
+				return
+			}
+		}
+	}
+}
+
+func (s *PortForwardSession) reconnect(ctx context.Context, cfg *portForwardConfig, dialer httpstream.Dialer, ports []string) (*forwarding, error) {
+	backoff := cfg.backoff
+	var lastErr error
+	for backoff.Steps > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, errPortForwardStopped
+		case <-s.stop:
+			return nil, errPortForwardStopped
+		case <-time.After(backoff.Step()):
+		}
+		f, err := startPortForward(dialer, cfg.addresses, ports)
+		if err == nil {
+			return f, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("failed to re-establish port-forward connection: %w", lastErr)
+}
+
+// forwarding the running port-forward over one connection. errCh receives
+// the result when the forwarding stops.
+type forwarding struct {
+	forwarder *portforward.PortForwarder
+	stopCh    chan struct{}
+	errCh     chan error
+}
+
+// stop stops the forwarding and waits for the listeners to be released
+func (f *forwarding) stop() {
+	close(f.stopCh)
+	<-f.errCh
+}
+
+// startPortForward starts forwarding and waits until it is ready
+func startPortForward(dialer httpstream.Dialer, addresses []string, ports []string) (*forwarding, error) {
+	f := &forwarding{stopCh: make(chan struct{}), errCh: make(chan error, 1)}
+	readyCh := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, addresses, ports, f.stopCh, readyCh, io.Discard, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	f.forwarder = forwarder
+	go func() { f.errCh <- forwarder.ForwardPorts() }()
+	select {
+	case <-readyCh:
+		return f, nil
+	case err = <-f.errCh:
+		if err == nil {
+			err = errors.New("port-forward stopped before ready")
+		}
+		return nil, err
+	}
+}
+
+// portForwardPath returns the API path for the port-forward subresource of
+// the pod, which goes through cluster-gateway for managed clusters
+func portForwardPath(apiPath string, cluster string, pod types.NamespacedName) string {
+	p := path.Join("/", apiPath, "api/v1/namespaces", pod.Namespace, "pods", pod.Name, "portforward")
+	if IsLocal(cluster) {
+		return p
+	}
+	return formatProxyURL(cluster, p)
+}
+
+// activityDialer records the data transferred through the connection
+type activityDialer struct {
+	httpstream.Dialer
+	touch func()
+}
+
+// Dial .
+func (d *activityDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	conn, protocol, err := d.Dialer.Dial(protocols...)
+	if err != nil {
+		return nil, protocol, err
+	}
+	return &activityConnection{Connection: conn, touch: d.touch}, protocol, nil
+}
+
+type activityConnection struct {
+	httpstream.Connection
+	touch func()
+}
+
+// CreateStream .
+func (c *activityConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	stream, err := c.Connection.CreateStream(headers)
+	if err != nil {
+		return nil, err
+	}
+	c.touch()
+	return &activityStream{Stream: stream, touch: c.touch}, nil
+}
+
+type activityStream struct {
+	httpstream.Stream
+	touch func()
+}
+
+// Read .
+func (s *activityStream) Read(p []byte) (int, error) {
+	n, err := s.Stream.Read(p)
+	if n > 0 {
+		s.touch()
+	}
+	return n, err
+}
+
+// Write .
+func (s *activityStream) Write(p []byte) (int, error) {
+	n, err := s.Stream.Write(p)
+	if n > 0 {
+		s.touch()
+	}
+	return n, err
+}
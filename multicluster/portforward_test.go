@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+)
+
+// newPortForwardServer creates the server echoing the forwarded data. The
+// first connection is dropped after the delay if it is positive.
+func newPortForwardServer(t *testing.T, dropAfter time.Duration) (*httptest.Server, *int32) {
+	connections := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/v1/namespaces/default/pods/example/portforward" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := httpstream.Handshake(req, w, []string{portforward.PortForwardProtocolV1Name}); err != nil {
+			return
+		}
+		conn := spdy.NewResponseUpgrader().UpgradeResponse(w, req, func(stream httpstream.Stream, replySent <-chan struct{}) error {
+			go func() {
+				<-replySent
+				if stream.Headers().Get(corev1.StreamType) == corev1.StreamTypeData {
+					_, _ = io.Copy(stream, stream)
+				}
+				_ = stream.Close()
+			}()
+			return nil
+		})
+		if conn == nil {
+			return
+		}
+		if atomic.AddInt32(connections, 1) == 1 && dropAfter > 0 {
+			time.AfterFunc(dropAfter, func() { _ = conn.Close() })
+		}
+		<-conn.CloseChan()
+	}))
+	return server, connections
+}
+
+func echoThroughPort(port uint16) (string, error) {
+	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if _, err = conn.Write([]byte("ping")); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 4)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = io.ReadFull(conn, buf)
+	return string(buf), err
+}
+
+func TestPortForward(t *testing.T) {
+	r := require.New(t)
+	server, connections := newPortForwardServer(t, 200*time.Millisecond)
+	defer server.Close()
+	pod := types.NamespacedName{Namespace: "default", Name: "example"}
+	session, err := PortForward(context.Background(), &rest.Config{Host: server.URL}, Local, pod, []string{":80"},
+		WithPortForwardReconnectBackoff(wait.Backoff{Duration: 50 * time.Millisecond, Steps: 5}))
+	r.NoError(err)
+	r.Len(session.Ports(), 1)
+	local := session.Ports()[0].Local
+	r.NotZero(local)
+	r.Equal(uint16(80), session.Ports()[0].Remote)
+	data, err := echoThroughPort(local)
+	r.NoError(err)
+	r.Equal("ping", data)
+
+	// reconnect with the same local port after the connection is dropped
+	r.Eventually(func() bool { return atomic.LoadInt32(connections) == 2 }, 5*time.Second, 50*time.Millisecond)
+	r.Eventually(func() bool {
+		data, err = echoThroughPort(local)
+		return err == nil && data == "ping"
+	}, 5*time.Second, 50*time.Millisecond)
+	r.NoError(session.Err())
+	session.Close()
+	r.NoError(session.Err())
+}
+
+func TestPortForwardCloseWhileReconnecting(t *testing.T) {
+	r := require.New(t)
+	server, connections := newPortForwardServer(t, 100*time.Millisecond)
+	defer server.Close()
+	pod := types.NamespacedName{Namespace: "default", Name: "example"}
+	session, err := PortForward(context.Background(), &rest.Config{Host: server.URL}, Local, pod, []string{":80"},
+		WithPortForwardReconnectBackoff(wait.Backoff{Duration: time.Minute, Steps: 5}))
+	r.NoError(err)
+	time.Sleep(500 * time.Millisecond)
+	r.Equal(int32(1), atomic.LoadInt32(connections))
+	session.Close()
+	r.NoError(session.Err())
+}
+
+func TestPortForwardIdleTimeout(t *testing.T) {
+	r := require.New(t)
+	server, _ := newPortForwardServer(t, 0)
+	defer server.Close()
+	pod := types.NamespacedName{Namespace: "default", Name: "example"}
+	session, err := PortForward(context.Background(), &rest.Config{Host: server.URL}, Local, pod, []string{":80"},
+		WithPortForwardIdleTimeout(200*time.Millisecond))
+	r.NoError(err)
+	select {
+	case <-session.Done():
+	case <-time.After(5 * time.Second):
+		r.Fail("session should be closed for idle")
+	}
+	r.ErrorIs(session.Err(), ErrPortForwardIdle)
+
+	// the idle check interval is clamped for tiny timeouts
+	session, err = PortForward(context.Background(), &rest.Config{Host: server.URL}, Local, pod, []string{":80"},
+		WithPortForwardIdleTimeout(time.Nanosecond))
+	r.NoError(err)
+	select {
+	case <-session.Done():
+	case <-time.After(5 * time.Second):
+		r.Fail("session should be closed for idle")
+	}
+	r.ErrorIs(session.Err(), ErrPortForwardIdle)
+
+	_, err = PortForward(context.Background(), &rest.Config{Host: server.URL}, Local,
+		types.NamespacedName{Namespace: "default", Name: "not-found"}, []string{":80"})
+	r.Error(err)
+}
+
+func TestPortForwardHostPathPrefix(t *testing.T) {
+	r := require.New(t)
+	backend, _ := newPortForwardServer(t, 0)
+	defer backend.Close()
+	server := httptest.NewServer(http.StripPrefix("/k8s/clusters/foo", backend.Config.Handler))
+	defer server.Close()
+	pod := types.NamespacedName{Namespace: "default", Name: "example"}
+	session, err := PortForward(context.Background(), &rest.Config{Host: server.URL + "/k8s/clusters/foo"}, Local, pod, []string{":80"})
+	r.NoError(err)
+	defer session.Close()
+	data, err := echoThroughPort(session.Ports()[0].Local)
+	r.NoError(err)
+	r.Equal("ping", data)
+}
+
+func TestPortForwardPath(t *testing.T) {
+	r := require.New(t)
+	pod := types.NamespacedName{Namespace: "default", Name: "example"}
+	r.Equal("/api/v1/namespaces/default/pods/example/portforward", portForwardPath("", Local, pod))
+	r.Equal("/apis/cluster.core.oam.dev/v1alpha1/clustergateways/managed/proxy/api/v1/namespaces/default/pods/example/portforward",
+		portForwardPath("", "managed", pod))
+}
@@ -0,0 +1,315 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// DefaultReadCacheRetention the default time for keeping the cached reads
+const DefaultReadCacheRetention = 10 * time.Minute
+
+// ReadPreference the preference for reading objects, which trades the
+// freshness of the data for the speed of the request
+type ReadPreference struct {
+	cached       bool
+	maxStaleness time.Duration
+}
+
+var (
+	// ReadPreferenceFresh always reads from the apiserver
+	ReadPreferenceFresh = ReadPreference{}
+	// ReadPreferenceCached reads from the cache whenever the object is cached
+	ReadPreferenceCached = ReadPreference{cached: true}
+)
+
+// ReadPreferenceCachedWithin reads from the cache if the object is cached
+// within the ttl, otherwise reads from the apiserver
+func ReadPreferenceCachedWithin(ttl time.Duration) ReadPreference {
+	return ReadPreference{cached: true, maxStaleness: ttl}
+}
+
+// String .
+func (p ReadPreference) String() string {
+	switch {
+	case !p.cached:
+		return "Fresh"
+	case p.maxStaleness > 0:
+		return fmt.Sprintf("CachedWithin(%s)", p.maxStaleness)
+	default:
+		return "Cached"
+	}
+}
+
+// ApplyToList allows ReadPreference to be passed as the option of List. It
+// does not change the list options.
+func (p ReadPreference) ApplyToList(*client.ListOptions) {}
+
+// accepts check if the data cached at the given time is acceptable
+func (p ReadPreference) accepts(cachedAt time.Time, now time.Time) bool {
+	return p.cached && (p.maxStaleness <= 0 || now.Sub(cachedAt) <= p.maxStaleness)
+}
+
+// WithReadPreference returns a copy of parent in which the read preference is
+// set
+func WithReadPreference(parent context.Context, preference ReadPreference) context.Context {
+	return context.WithValue(parent, readPreferenceKey, preference)
+}
+
+// ReadPreferenceFrom returns the read preference on the ctx
+func ReadPreferenceFrom(ctx context.Context) (ReadPreference, bool) {
+	preference, ok := ctx.Value(readPreferenceKey).(ReadPreference)
+	return preference, ok
+}
+
+// readCacheEntry the object read from the cluster
+type readCacheEntry struct {
+	obj      runtime.Object
+	cachedAt time.Time
+}
+
+// readPreferenceClient serves the reads by the read preference of the request
+// from the cached reads or the delegated client
+type readPreferenceClient struct {
+	client.Client
+	hubCache          client.Reader
+	defaultPreference ReadPreference
+	retention         time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]readCacheEntry
+	lastSweep time.Time
+	// generations the number of invalidations for each kind prefix, so that
+	// reads started before the invalidation are not cached
+	generations map[string]uint64
+}
+
+// readPreferenceStatusWriter drops the cached objects once written
+type readPreferenceStatusWriter struct {
+	client.StatusWriter
+	c *readPreferenceClient
+}
+
+var _ client.Client = &readPreferenceClient{}
+var _ client.StatusWriter = &readPreferenceStatusWriter{}
+
+// ReadPreferenceClientOption the option for creating read preference client
+type ReadPreferenceClientOption interface {
+	ApplyToReadPreferenceClient(*readPreferenceClient)
+}
+
+// WithHubCache set the informer cache of the hub cluster, which is used for
+// cached reads on the hub cluster instead of the cached reads of the client
+type WithHubCache struct {
+	client.Reader
+}
+
+// ApplyToReadPreferenceClient .
+func (op WithHubCache) ApplyToReadPreferenceClient(c *readPreferenceClient) {
+	c.hubCache = op.Reader
+}
+
+// WithDefaultReadPreference set the read preference for requests without
+// preference. Defaults to ReadPreferenceFresh.
+type WithDefaultReadPreference ReadPreference
+
+// ApplyToReadPreferenceClient .
+func (op WithDefaultReadPreference) ApplyToReadPreferenceClient(c *readPreferenceClient) {
+	c.defaultPreference = ReadPreference(op)
+}
+
+// WithReadCacheRetention set the time for keeping the cached reads. Reads
+// older than the retention are never served.
+type WithReadCacheRetention time.Duration
+
+// ApplyToReadPreferenceClient .
+func (op WithReadCacheRetention) ApplyToReadPreferenceClient(c *readPreferenceClient) {
+	c.retention = time.Duration(op)
+}
+
+// NewReadPreferenceClient wraps the client to serve Get and List by the read
+// preference set in the context or passed as the list option. Objects read
+// from the apiserver are cached per cluster, and the cached objects of the
+// same kind in the cluster are dropped once written through the client.
+func NewReadPreferenceClient(cli client.Client, options ...ReadPreferenceClientOption) client.Client {
+	c := &readPreferenceClient{
+		Client:      cli,
+		retention:   DefaultReadCacheRetention,
+		entries:     map[string]readCacheEntry{},
+		generations: map[string]uint64{},
+	}
+	for _, op := range options {
+		op.ApplyToReadPreferenceClient(c)
+	}
+	return c
+}
+
+func (c *readPreferenceClient) getPreference(ctx context.Context, opts []client.ListOption) ReadPreference {
+	for _, op := range opts {
+		if preference, ok := op.(ReadPreference); ok {
+			return preference
+		}
+	}
+	if preference, ok := ReadPreferenceFrom(ctx); ok {
+		return preference
+	}
+	return c.defaultPreference
+}
+
+// getKindPrefix returns the prefix of the cache keys for the kind in the
+// cluster of the request
+func getKindPrefix(ctx context.Context, gvk schema.GroupVersionKind) string {
+	cluster, _ := ClusterFrom(ctx)
+	if IsLocal(cluster) {
+		cluster = Local
+	}
+	return fmt.Sprintf("%s/%s/", cluster, gvk.String())
+}
+
+// read serves the request from the cache if acceptable, otherwise reads
+// through fn and caches the result
+func (c *readPreferenceClient) read(ctx context.Context, obj runtime.Object, key string, preference ReadPreference, fn func() error) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return fn()
+	}
+	prefix := getKindPrefix(ctx, gvk)
+	key = fmt.Sprintf("%s%T/%s", prefix, obj, key)
+	now := time.Now()
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	generation := c.generations[prefix]
+	c.mu.Unlock()
+	if preference.cached && found && now.Sub(entry.cachedAt) <= c.retention && preference.accepts(entry.cachedAt, now) {
+		reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(entry.obj.DeepCopyObject()).Elem())
+		return nil
+	}
+	if err = fn(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// the result may be stale if the kind is written during the read
+	if c.generations[prefix] != generation {
+		return nil
+	}
+	c.entries[key] = readCacheEntry{obj: obj.DeepCopyObject(), cachedAt: now}
+	if now.Sub(c.lastSweep) > c.retention {
+		for k, entry := range c.entries {
+			if now.Sub(entry.cachedAt) > c.retention {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	return nil
+}
+
+// invalidate drops the cached objects and lists of the same kind in the
+// cluster
+func (c *readPreferenceClient) invalidate(ctx context.Context, obj runtime.Object) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return
+	}
+	prefix := getKindPrefix(ctx, gvk)
+	gvk.Kind += "List"
+	listPrefix := getKindPrefix(ctx, gvk)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generations[prefix]++
+	c.generations[listPrefix]++
+	for k := range c.entries {
+		if strings.HasPrefix(k, prefix) || strings.HasPrefix(k, listPrefix) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+func (c *readPreferenceClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	preference := c.getPreference(ctx, nil)
+	if cluster, _ := ClusterFrom(ctx); c.hubCache != nil && IsLocal(cluster) && preference.cached {
+		return c.hubCache.Get(ctx, key, obj)
+	}
+	return c.read(ctx, obj, key.String(), preference, func() error {
+		return c.Client.Get(ctx, key, obj)
+	})
+}
+
+func (c *readPreferenceClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	preference := c.getPreference(ctx, opts)
+	if cluster, _ := ClusterFrom(ctx); c.hubCache != nil && IsLocal(cluster) && preference.cached {
+		return c.hubCache.List(ctx, list, opts...)
+	}
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	raw := listOpts.AsListOptions()
+	key := fmt.Sprintf("%s?labels=%s&fields=%s&limit=%d&continue=%s",
+		listOpts.Namespace, raw.LabelSelector, raw.FieldSelector, raw.Limit, raw.Continue)
+	return c.read(ctx, list, key, preference, func() error {
+		return c.Client.List(ctx, list, opts...)
+	})
+}
+
+func (c *readPreferenceClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	defer c.invalidate(ctx, obj)
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *readPreferenceClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	defer c.invalidate(ctx, obj)
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *readPreferenceClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	defer c.invalidate(ctx, obj)
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *readPreferenceClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	defer c.invalidate(ctx, obj)
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *readPreferenceClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	defer c.invalidate(ctx, obj)
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *readPreferenceClient) Status() client.StatusWriter {
+	return &readPreferenceStatusWriter{StatusWriter: c.Client.Status(), c: c}
+}
+
+func (w *readPreferenceStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	defer w.c.invalidate(ctx, obj)
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *readPreferenceStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	defer w.c.invalidate(ctx, obj)
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
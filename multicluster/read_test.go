@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type readCountingClient struct {
+	client.Client
+	reads int32
+}
+
+func (c *readCountingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	atomic.AddInt32(&c.reads, 1)
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *readCountingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	atomic.AddInt32(&c.reads, 1)
+	return c.Client.List(ctx, list, opts...)
+}
+
+// slowReadClient holds the read result until released
+type slowReadClient struct {
+	client.Client
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (c *slowReadClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	err := c.Client.Get(ctx, key, obj)
+	if c.read != nil {
+		c.read <- struct{}{}
+		<-c.release
+	}
+	return err
+}
+
+func TestReadPreferenceClientStaleRead(t *testing.T) {
+	r := require.New(t)
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"}, Data: map[string]string{"k": "v1"}}
+	base := &slowReadClient{Client: fake.NewClientBuilder().WithObjects(cm).Build(), read: make(chan struct{}), release: make(chan struct{})}
+	cli := NewReadPreferenceClient(base, WithDefaultReadPreference(ReadPreferenceCached))
+	ctx := WithCluster(context.Background(), "managed")
+
+	// the read started before the write is not cached
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		obj := &corev1.ConfigMap{}
+		r.NoError(cli.Get(ctx, client.ObjectKeyFromObject(cm), obj))
+		r.Equal("v1", obj.Data["k"])
+	}()
+	<-base.read
+	cm.Data["k"] = "v2"
+	r.NoError(cli.Update(ctx, cm))
+	base.release <- struct{}{}
+	<-done
+
+	base.read = nil
+	obj := &corev1.ConfigMap{}
+	r.NoError(cli.Get(ctx, client.ObjectKeyFromObject(cm), obj))
+	r.Equal("v2", obj.Data["k"])
+}
+
+func TestReadPreferenceClient(t *testing.T) {
+	r := require.New(t)
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"}, Data: map[string]string{"k": "v1"}}
+	base := &readCountingClient{Client: fake.NewClientBuilder().WithObjects(cm).Build()}
+	cli := NewReadPreferenceClient(base)
+	ctx := WithCluster(context.Background(), "managed")
+	key := client.ObjectKeyFromObject(cm)
+
+	get := func(ctx context.Context) string {
+		obj := &corev1.ConfigMap{}
+		r.NoError(cli.Get(ctx, key, obj))
+		return obj.Data["k"]
+	}
+	r.Equal("v1", get(ctx))
+	r.Equal("v1", get(WithReadPreference(ctx, ReadPreferenceCached)))
+	r.Equal(int32(1), atomic.LoadInt32(&base.reads))
+	r.Equal("v1", get(ctx))
+	r.Equal(int32(2), atomic.LoadInt32(&base.reads))
+
+	// cached reads are separated by clusters
+	r.Equal("v1", get(WithReadPreference(WithCluster(context.Background(), "other"), ReadPreferenceCached)))
+	r.Equal(int32(3), atomic.LoadInt32(&base.reads))
+
+	// writes through the client drop the cached reads
+	cm.Data["k"] = "v2"
+	r.NoError(base.Update(ctx, cm))
+	r.Equal("v1", get(WithReadPreference(ctx, ReadPreferenceCachedWithin(time.Hour))))
+	r.Equal(int32(3), atomic.LoadInt32(&base.reads))
+	time.Sleep(20 * time.Millisecond)
+	r.Equal("v2", get(WithReadPreference(ctx, ReadPreferenceCachedWithin(10*time.Millisecond))))
+	r.Equal(int32(4), atomic.LoadInt32(&base.reads))
+	r.NoError(cli.Update(ctx, cm))
+	r.Equal("v2", get(WithReadPreference(ctx, ReadPreferenceCached)))
+	r.Equal(int32(5), atomic.LoadInt32(&base.reads))
+
+	list := func(ctx context.Context, opts ...client.ListOption) int {
+		cms := &corev1.ConfigMapList{}
+		r.NoError(cli.List(ctx, cms, opts...))
+		return len(cms.Items)
+	}
+	r.Equal(1, list(ctx, client.InNamespace("default")))
+	r.Equal(1, list(ctx, client.InNamespace("default"), ReadPreferenceCached))
+	r.Equal(int32(6), atomic.LoadInt32(&base.reads))
+	r.Equal(0, list(ctx, client.InNamespace("vela-system"), ReadPreferenceCached))
+	r.Equal(int32(7), atomic.LoadInt32(&base.reads))
+	r.NoError(cli.Patch(ctx, cm, client.MergeFrom(cm.DeepCopy())))
+	r.Equal(1, list(ctx, client.InNamespace("default"), ReadPreferenceCached))
+	r.Equal(int32(8), atomic.LoadInt32(&base.reads))
+
+	// cached reads on the hub cluster are served by the hub cache
+	hubCache := &readCountingClient{Client: base.Client}
+	cli = NewReadPreferenceClient(base, WithHubCache{hubCache}, WithDefaultReadPreference(ReadPreferenceCached))
+	r.Equal("v2", get(context.Background()))
+	r.Equal(1, list(context.Background()))
+	r.Equal(int32(2), atomic.LoadInt32(&hubCache.reads))
+	r.Equal(int32(8), atomic.LoadInt32(&base.reads))
+
+	r.Equal("Fresh", ReadPreferenceFresh.String())
+	r.Equal("Cached", ReadPreferenceCached.String())
+	r.Equal("CachedWithin(1m0s)", ReadPreferenceCachedWithin(time.Minute).String())
+}
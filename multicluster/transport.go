@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"path"
 	"strings"
+	"time"
 
 	clustergatewayconfig "github.com/oam-dev/cluster-gateway/pkg/config"
 	knet "k8s.io/apimachinery/pkg/util/net"
@@ -37,6 +38,11 @@ type Transport struct {
 	// cluster the proxy target. If empty, the target will be determined from
 	// request context dynamically.
 	cluster *string
+
+	// auditSink records the requests to managed clusters if set
+	auditSink AuditSink
+	// auditIdentity the identity recorded for requests without explicit user
+	auditIdentity string
 }
 
 // TransportOption option for creating transport
@@ -100,11 +106,27 @@ func (t *Transport) getClusterFor(req *http.Request) string {
 // RoundTrip is the main function for the re-write API path logic
 func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	cluster := t.getClusterFor(req)
-	if !IsLocal(cluster) {
-		req = req.Clone(req.Context())
-		req.URL.Path = formatProxyURL(cluster, req.URL.Path)
+	if IsLocal(cluster) {
+		return t.delegate.RoundTrip(req)
+	}
+	var event AuditEvent
+	if t.auditSink != nil {
+		event = newAuditEvent(cluster, req, t.auditIdentity)
+	}
+	req = req.Clone(req.Context())
+	req.URL.Path = formatProxyURL(cluster, req.URL.Path)
+	resp, err := t.delegate.RoundTrip(req)
+	if t.auditSink != nil {
+		event.Latency = time.Since(event.Timestamp)
+		if resp != nil {
+			event.Code = resp.StatusCode
+		}
+		if err != nil {
+			event.Error = err.Error()
+		}
+		t.auditSink.Record(event)
 	}
-	return t.delegate.RoundTrip(req)
+	return resp, err
 }
 
 // CancelRequest will try cancel request with the inner round tripper
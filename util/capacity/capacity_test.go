@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func resources(cpu, memory string) corev1.ResourceList {
+	return corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)}
+}
+
+func podSpec(requests, limits corev1.ResourceList) corev1.PodSpec {
+	return corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits}}}}
+}
+
+func requireResources(t *testing.T, expected, actual corev1.ResourceList) {
+	require.Equal(t, len(expected), len(actual))
+	for name, q := range expected {
+		require.True(t, q.Equal(actual[name]), "%s: expected %s, got %s", name, q.String(), actual.Name(name, resource.DecimalSI).String())
+	}
+}
+
+func TestPodResources(t *testing.T) {
+	spec := podSpec(resources("500m", "256Mi"), resources("1", "512Mi"))
+	spec.Containers = append(spec.Containers, corev1.Container{Name: "sidecar", Resources: corev1.ResourceRequirements{
+		Requests: resources("100m", "64Mi"), Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")}}})
+	spec.InitContainers = []corev1.Container{{Name: "init", Resources: corev1.ResourceRequirements{Requests: resources("2", "32Mi")}}}
+	spec.Overhead = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("10Mi")}
+	requireResources(t, resources("2", "330Mi"), PodRequests(&spec))
+	requireResources(t, corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1200m")}, PodLimits(&spec))
+}
+
+func TestAggregate(t *testing.T) {
+	r := require.New(t)
+	deploy := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: appsv1.DeploymentSpec{Replicas: pointer.Int32(3), Template: corev1.PodTemplateSpec{
+			Spec: podSpec(resources("500m", "256Mi"), resources("1", "512Mi"))}},
+	}
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(deploy)
+	r.NoError(err)
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "agent"},
+		Spec:       appsv1.DaemonSetSpec{Template: corev1.PodTemplateSpec{Spec: podSpec(resources("100m", "64Mi"), nil)}},
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config"}}
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "example.com/v1", "kind": "Unknown"}}
+	summary, err := Aggregate([]runtime.Object{&unstructured.Unstructured{Object: raw}, ds, cm, crd})
+	r.NoError(err)
+	r.Equal(int32(3), summary.Pods)
+	requireResources(t, resources("1500m", "768Mi"), summary.Requests)
+	requireResources(t, resources("3", "1536Mi"), summary.Limits)
+	requireResources(t, resources("100m", "64Mi"), summary.PerNode)
+	r.Equal(2, len(summary.Demands))
+	r.Equal("Deployment/default/web", summary.Demands[0].Workload)
+	r.True(summary.Demands[1].PerNode)
+}
+
+func TestEstimateFit(t *testing.T) {
+	r := require.New(t)
+	allocatable := func(cpu, memory string, pods int64) corev1.ResourceList {
+		list := resources(cpu, memory)
+		list[corev1.ResourcePods] = *resource.NewQuantity(pods, resource.DecimalSI)
+		return list
+	}
+	cli := fake.NewClientBuilder().WithObjects(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}, Status: corev1.NodeStatus{Allocatable: allocatable("3", "4Gi", 10)}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}, Status: corev1.NodeStatus{Allocatable: allocatable("4", "8Gi", 2)}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "cordoned"}, Spec: corev1.NodeSpec{Unschedulable: true},
+			Status: corev1.NodeStatus{Allocatable: allocatable("64", "256Gi", 100)}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "running"},
+			Spec: func() corev1.PodSpec {
+				spec := podSpec(resources("1", "1Gi"), nil)
+				spec.NodeName = "node-b"
+				return spec
+			}()},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "completed"},
+			Spec:   corev1.PodSpec{NodeName: "node-a", Containers: []corev1.Container{{Name: "main"}}},
+			Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+	).Build()
+	nodes, err := GetNodeCapacities(context.Background(), cli)
+	r.NoError(err)
+	r.Equal(2, len(nodes))
+	r.Equal("node-a", nodes[0].Name)
+	requireResources(t, allocatable("2", "3Gi", 9), nodes[1].Free())
+
+	daemon := PodDemand{Workload: "DaemonSet/kube-system/agent", Requests: resources("100m", "64Mi"), PerNode: true}
+	result := EstimateFit(nodes, []PodDemand{
+		{Workload: "Deployment/default/small", Requests: resources("500m", "512Mi"), Replicas: 2},
+		{Workload: "Deployment/default/large", Requests: resources("3", "1Gi"), Replicas: 1},
+		daemon,
+	})
+	r.True(result.Fits)
+	r.Equal(map[string]int32{"node-a": 2, "node-b": 3}, result.Placed)
+
+	result = EstimateFit(nodes, []PodDemand{
+		{Workload: "Deployment/default/small", Requests: resources("500m", "512Mi"), Replicas: 5},
+		{Workload: "Deployment/default/gpu", Requests: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}, Replicas: 1},
+		daemon,
+	})
+	r.False(result.Fits)
+	r.Equal(2, len(result.Unplaced))
+	r.Equal("Deployment/default/small", result.Unplaced[0].Workload)
+	r.Equal(int32(1), result.Unplaced[0].Replicas)
+	r.Equal("Deployment/default/gpu", result.Unplaced[1].Workload)
+}
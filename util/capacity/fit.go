@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NodeCapacity the schedulable resources of a node
+type NodeCapacity struct {
+	Name        string
+	Allocatable corev1.ResourceList
+	// Requested the resources requested by the pods on the node
+	Requested corev1.ResourceList
+	// Pods the number of pods on the node
+	Pods int64
+}
+
+// Free returns the allocatable resources not requested yet, including the
+// number of pods left
+func (n *NodeCapacity) Free() corev1.ResourceList {
+	free := corev1.ResourceList{}
+	for name, q := range n.Allocatable {
+		free[name] = q.DeepCopy()
+	}
+	Sub(free, n.Requested)
+	if pods, found := free[corev1.ResourcePods]; found {
+		pods.Set(pods.Value() - n.Pods)
+		free[corev1.ResourcePods] = pods
+	}
+	return free
+}
+
+// GetNodeCapacities returns the capacities of the schedulable nodes with the
+// requests of the scheduled and running pods. The cluster can be selected
+// by the context for multi-cluster clients.
+func GetNodeCapacities(ctx context.Context, cli client.Client) ([]NodeCapacity, error) {
+	nodes := &corev1.NodeList{}
+	if err := cli.List(ctx, nodes); err != nil {
+		return nil, err
+	}
+	capacities := map[string]*NodeCapacity{}
+	var names []string
+	for _, node := range nodes.Items {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		capacities[node.Name] = &NodeCapacity{Name: node.Name, Allocatable: node.Status.Allocatable.DeepCopy(), Requested: corev1.ResourceList{}}
+		names = append(names, node.Name)
+	}
+	pods := &corev1.PodList{}
+	if err := cli.List(ctx, pods); err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if capacity, found := capacities[pod.Spec.NodeName]; found {
+			Add(capacity.Requested, PodRequests(&pod.Spec))
+			capacity.Pods++
+		}
+	}
+	sort.Strings(names)
+	result := make([]NodeCapacity, 0, len(names))
+	for _, name := range names {
+		result = append(result, *capacities[name])
+	}
+	return result, nil
+}
+
+// FitResult the estimated placement of the demands on the nodes
+type FitResult struct {
+	// Fits whether all the pods can be placed
+	Fits bool
+	// Placed the number of pods placed on each node
+	Placed map[string]int32
+	// Unplaced the pods cannot be placed, with Replicas set to the number of
+	// the pods left. Per-node demands are reported with the number of nodes
+	// they do not fit.
+	Unplaced []PodDemand
+}
+
+// EstimateFit estimates if the pods fit into the nodes through first-fit
+// decreasing bin-packing on the free resources. Per-node pods are placed on
+// every node first. Node selectors, affinities and taints are not considered,
+// so the result is optimistic.
+func EstimateFit(nodes []NodeCapacity, demands []PodDemand) FitResult {
+	free := make([]corev1.ResourceList, len(nodes))
+	for i := range nodes {
+		free[i] = nodes[i].Free()
+	}
+	result := FitResult{Placed: map[string]int32{}}
+	// the number of pods is limited only if the node reports it
+	place := func(i int, demand corev1.ResourceList) bool {
+		pods, limited := free[i][corev1.ResourcePods]
+		if limited && pods.Value() < 1 || !Fits(demand, free[i]) {
+			return false
+		}
+		Sub(free[i], demand)
+		if limited {
+			pods.Set(pods.Value() - 1)
+			free[i][corev1.ResourcePods] = pods
+		}
+		result.Placed[nodes[i].Name]++
+		return true
+	}
+
+	var replicated []PodDemand
+	for _, d := range demands {
+		if !d.PerNode {
+			replicated = append(replicated, d)
+			continue
+		}
+		unplaced := int32(0)
+		for i := range nodes {
+			if !place(i, d.Requests) {
+				unplaced++
+			}
+		}
+		if unplaced > 0 {
+			d.Replicas = unplaced
+			result.Unplaced = append(result.Unplaced, d)
+		}
+	}
+
+	sort.SliceStable(replicated, func(i, j int) bool {
+		return isLarger(replicated[i].Requests, replicated[j].Requests)
+	})
+	for _, d := range replicated {
+		unplaced := int32(0)
+		for n := int32(0); n < d.Replicas; n++ {
+			placed := false
+			for i := range nodes {
+				if placed = place(i, d.Requests); placed {
+					break
+				}
+			}
+			if !placed {
+				unplaced = d.Replicas - n
+				break
+			}
+		}
+		if unplaced > 0 {
+			d.Replicas = unplaced
+			result.Unplaced = append(result.Unplaced, d)
+		}
+	}
+	result.Fits = len(result.Unplaced) == 0
+	return result
+}
+
+// isLarger compares the requests by cpu and then memory
+func isLarger(a, b corev1.ResourceList) bool {
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		qa, qb := a[name], b[name]
+		if c := qa.Cmp(qb); c != 0 {
+			return c > 0
+		}
+	}
+	return false
+}
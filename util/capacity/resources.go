@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// PodRequests returns the effective requests of the pod, which is the larger
+// one between the sum of containers and any init container, plus overhead
+func PodRequests(spec *corev1.PodSpec) corev1.ResourceList {
+	return podResources(spec, func(r corev1.ResourceRequirements) corev1.ResourceList { return r.Requests })
+}
+
+// PodLimits returns the effective limits of the pod in the same way as
+// PodRequests. Resources not limited by all the containers are not limited
+// for the pod either and are excluded.
+func PodLimits(spec *corev1.PodSpec) corev1.ResourceList {
+	limits := podResources(spec, func(r corev1.ResourceRequirements) corev1.ResourceList { return r.Limits })
+	for name := range limits {
+		for _, c := range spec.Containers {
+			if _, found := c.Resources.Limits[name]; !found {
+				delete(limits, name)
+				break
+			}
+		}
+	}
+	return limits
+}
+
+func podResources(spec *corev1.PodSpec, get func(corev1.ResourceRequirements) corev1.ResourceList) corev1.ResourceList {
+	total := corev1.ResourceList{}
+	for _, c := range spec.Containers {
+		Add(total, get(c.Resources))
+	}
+	for _, c := range spec.InitContainers {
+		for name, q := range get(c.Resources) {
+			if current, found := total[name]; !found || q.Cmp(current) > 0 {
+				total[name] = q.DeepCopy()
+			}
+		}
+	}
+	Add(total, spec.Overhead)
+	return total
+}
+
+// Add adds delta into total
+func Add(total corev1.ResourceList, delta corev1.ResourceList) {
+	for name, q := range delta {
+		sum := total[name]
+		sum.Add(q)
+		total[name] = sum
+	}
+}
+
+// Sub subtracts delta from total. Resources not in total are ignored.
+func Sub(total corev1.ResourceList, delta corev1.ResourceList) {
+	for name, q := range delta {
+		if current, found := total[name]; found {
+			current.Sub(q)
+			total[name] = current
+		}
+	}
+}
+
+// Multiply returns the resources multiplied by n
+func Multiply(list corev1.ResourceList, n int64) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for name, q := range list {
+		if name == corev1.ResourceCPU {
+			result[name] = *resource.NewMilliQuantity(q.MilliValue()*n, q.Format)
+		} else {
+			result[name] = *resource.NewQuantity(q.Value()*n, q.Format)
+		}
+	}
+	return result
+}
+
+// Fits check if the demand fits into the available resources. Resources not
+// in available are treated as zero.
+func Fits(demand corev1.ResourceList, available corev1.ResourceList) bool {
+	for name, q := range demand {
+		left := available[name]
+		if q.Cmp(left) > 0 {
+			return false
+		}
+	}
+	return true
+}
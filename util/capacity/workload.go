@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// PodDemand the resources demanded by the pods of one workload
+type PodDemand struct {
+	// Workload the identity of the workload in the format of kind/namespace/name
+	Workload string
+	// Requests the requests of each pod
+	Requests corev1.ResourceList
+	// Limits the limits of each pod
+	Limits corev1.ResourceList
+	// Replicas the number of pods. It is ignored if PerNode is set.
+	Replicas int32
+	// PerNode the workload runs one pod on every node, like DaemonSet
+	PerNode bool
+}
+
+// Summary the aggregated resources of workloads
+type Summary struct {
+	// Requests the total requests of the pods, excluding the per-node ones
+	Requests corev1.ResourceList
+	// Limits the total limits of the pods, excluding the per-node ones
+	Limits corev1.ResourceList
+	// Pods the number of pods, excluding the per-node ones
+	Pods int32
+	// PerNode the requests of the per-node pods on each node
+	PerNode corev1.ResourceList
+	// Demands the demands of each workload
+	Demands []PodDemand
+}
+
+// GetPodDemand returns the demand of the workload. Pods, Deployments,
+// ReplicaSets, StatefulSets, DaemonSets, Jobs and CronJobs are supported,
+// either typed or unstructured. It returns nil for other objects.
+func GetPodDemand(obj runtime.Object) (*PodDemand, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		typed, err := scheme.Scheme.New(u.GroupVersionKind())
+		if err != nil {
+			if runtime.IsNotRegisteredError(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, typed); err != nil {
+			return nil, err
+		}
+		obj = typed
+	}
+	replicas := func(r *int32) int32 {
+		if r == nil {
+			return 1
+		}
+		return *r
+	}
+	var spec *corev1.PodSpec
+	demand := &PodDemand{}
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		spec, demand.Replicas = &o.Spec, 1
+		demand.Workload = fmt.Sprintf("Pod/%s/%s", o.Namespace, o.Name)
+	case *appsv1.Deployment:
+		spec, demand.Replicas = &o.Spec.Template.Spec, replicas(o.Spec.Replicas)
+		demand.Workload = fmt.Sprintf("Deployment/%s/%s", o.Namespace, o.Name)
+	case *appsv1.ReplicaSet:
+		spec, demand.Replicas = &o.Spec.Template.Spec, replicas(o.Spec.Replicas)
+		demand.Workload = fmt.Sprintf("ReplicaSet/%s/%s", o.Namespace, o.Name)
+	case *appsv1.StatefulSet:
+		spec, demand.Replicas = &o.Spec.Template.Spec, replicas(o.Spec.Replicas)
+		demand.Workload = fmt.Sprintf("StatefulSet/%s/%s", o.Namespace, o.Name)
+	case *appsv1.DaemonSet:
+		spec, demand.PerNode = &o.Spec.Template.Spec, true
+		demand.Workload = fmt.Sprintf("DaemonSet/%s/%s", o.Namespace, o.Name)
+	case *batchv1.Job:
+		spec, demand.Replicas = &o.Spec.Template.Spec, replicas(o.Spec.Parallelism)
+		demand.Workload = fmt.Sprintf("Job/%s/%s", o.Namespace, o.Name)
+	case *batchv1.CronJob:
+		spec, demand.Replicas = &o.Spec.JobTemplate.Spec.Template.Spec, replicas(o.Spec.JobTemplate.Spec.Parallelism)
+		demand.Workload = fmt.Sprintf("CronJob/%s/%s", o.Namespace, o.Name)
+	default:
+		return nil, nil
+	}
+	demand.Requests = PodRequests(spec)
+	demand.Limits = PodLimits(spec)
+	return demand, nil
+}
+
+// Aggregate sums up the demands of the workloads. Objects that are not
+// workloads are ignored.
+func Aggregate(objs []runtime.Object) (*Summary, error) {
+	summary := &Summary{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}, PerNode: corev1.ResourceList{}}
+	for _, obj := range objs {
+		demand, err := GetPodDemand(obj)
+		if err != nil {
+			return nil, err
+		}
+		if demand == nil {
+			continue
+		}
+		summary.Demands = append(summary.Demands, *demand)
+		if demand.PerNode {
+			Add(summary.PerNode, demand.Requests)
+			continue
+		}
+		Add(summary.Requests, Multiply(demand.Requests, int64(demand.Replicas)))
+		Add(summary.Limits, Multiply(demand.Limits, int64(demand.Replicas)))
+		summary.Pods += demand.Replicas
+	}
+	return summary, nil
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff3
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DefaultConflictAnnotation the annotation suggested for recording the
+// conflicts of the merge
+const DefaultConflictAnnotation = "diff3.oam.dev/conflicts"
+
+// Conflict the field changed differently in the modified and the live object.
+// The values are nil if the field does not exist in the object.
+type Conflict struct {
+	Path     string
+	Original interface{}
+	Modified interface{}
+	Live     interface{}
+}
+
+// ConflictError the error for conflicts with ConflictStrategyError
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+// Error .
+func (e *ConflictError) Error() string {
+	paths := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		paths = append(paths, c.Path)
+	}
+	return fmt.Sprintf("%d conflicts found in three-way merge: [%s]", len(paths), strings.Join(paths, ", "))
+}
+
+// Merge merges the changes from original to modified into the live object,
+// and keeps the changes made by others in the live object. Fields changed
+// differently in both the modified and the live object are conflicts, which
+// are resolved by the configured strategy and returned. The input objects are
+// not changed.
+func Merge(original, modified, live *unstructured.Unstructured, options ...MergeOption) (*unstructured.Unstructured, []Conflict, error) {
+	var o, m, l map[string]interface{}
+	if original != nil {
+		o = original.Object
+	}
+	if modified != nil {
+		m = modified.Object
+	}
+	if live != nil {
+		l = live.Object
+	}
+	cfg := newMergeConfig(options...)
+	merged, conflicts, err := MergeObjects(o, m, l, options...)
+	if err != nil {
+		return nil, conflicts, err
+	}
+	result := &unstructured.Unstructured{Object: merged}
+	if cfg.annotation != "" && len(conflicts) > 0 {
+		paths := make([]string, 0, len(conflicts))
+		for _, c := range conflicts {
+			paths = append(paths, c.Path)
+		}
+		bs, err := json.Marshal(paths)
+		if err != nil {
+			return nil, conflicts, err
+		}
+		annotations := result.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[cfg.annotation] = string(bs)
+		result.SetAnnotations(annotations)
+	}
+	return result, conflicts, nil
+}
+
+// MergeObjects is the same as Merge but works on the unstructured content
+func MergeObjects(original, modified, live map[string]interface{}, options ...MergeOption) (map[string]interface{}, []Conflict, error) {
+	m := &merger{cfg: newMergeConfig(options...)}
+	value, _, err := m.merge(nil, nil,
+		field{value: original, exists: original != nil},
+		field{value: modified, exists: modified != nil},
+		field{value: live, exists: live != nil})
+	if err != nil {
+		return nil, m.conflicts, err
+	}
+	if len(m.conflicts) > 0 && m.cfg.resolver == nil && m.cfg.strategy == ConflictStrategyError {
+		return nil, m.conflicts, &ConflictError{Conflicts: m.conflicts}
+	}
+	merged, _ := value.(map[string]interface{})
+	if merged == nil {
+		merged = map[string]interface{}{}
+	}
+	return merged, m.conflicts, nil
+}
+
+type field struct {
+	value  interface{}
+	exists bool
+}
+
+func (f field) equal(other field) bool {
+	return f.exists == other.exists && reflect.DeepEqual(f.value, other.value)
+}
+
+// get returns the copy of the value for the merged object
+func (f field) get() (interface{}, bool, error) {
+	if !f.exists {
+		return nil, false, nil
+	}
+	return runtime.DeepCopyJSONValue(f.value), true, nil
+}
+
+type merger struct {
+	cfg       mergeConfig
+	conflicts []Conflict
+}
+
+// merge merges the field, where path is the field path for display and
+// fields is the path of field names for matching merge keys
+func (m *merger) merge(path []string, fields []string, original, modified, live field) (interface{}, bool, error) {
+	switch {
+	case modified.equal(original):
+		return live.get()
+	case live.equal(original), modified.equal(live):
+		return modified.get()
+	}
+	modifiedMap, isModifiedMap := modified.value.(map[string]interface{})
+	liveMap, isLiveMap := live.value.(map[string]interface{})
+	if isModifiedMap && isLiveMap {
+		originalMap, _ := original.value.(map[string]interface{})
+		return m.mergeMap(path, fields, originalMap, modifiedMap, liveMap)
+	}
+	modifiedList, isModifiedList := modified.value.([]interface{})
+	liveList, isLiveList := live.value.([]interface{})
+	if key, found := m.cfg.mergeKeys[strings.Join(fields, ".")]; found && isModifiedList && isLiveList {
+		originalList, _ := original.value.([]interface{})
+		if value, ok, err := m.mergeList(path, fields, key, originalList, modifiedList, liveList); ok || err != nil {
+			return value, true, err
+		}
+	}
+	conflict := Conflict{Path: strings.Join(path, "."), Original: original.value, Modified: modified.value, Live: live.value}
+	return m.resolve(conflict, modified, live)
+}
+
+func (m *merger) mergeMap(path []string, fields []string, original, modified, live map[string]interface{}) (interface{}, bool, error) {
+	merged := map[string]interface{}{}
+	seen := map[string]struct{}{}
+	var keys []string
+	for _, obj := range []map[string]interface{}{original, modified, live} {
+		for k := range obj {
+			if _, found := seen[k]; !found {
+				seen[k] = struct{}{}
+				keys = append(keys, k)
+			}
+		}
+	}
+	// merge in order so that the conflicts are stable across runs
+	sort.Strings(keys)
+	for _, k := range keys {
+		o, oExists := original[k]
+		mod, mExists := modified[k]
+		l, lExists := live[k]
+		value, exists, err := m.merge(append(path[:len(path):len(path)], k), append(fields[:len(fields):len(fields)], k),
+			field{value: o, exists: oExists}, field{value: mod, exists: mExists}, field{value: l, exists: lExists})
+		if err != nil {
+			return nil, false, err
+		}
+		if exists {
+			merged[k] = value
+		}
+	}
+	return merged, true, nil
+}
+
+// mergeList merges the elements identified by the merge key. The merged list
+// follows the order of the live list with new elements from the modified list
+// appended. It returns false if any element cannot be identified.
+func (m *merger) mergeList(path []string, fields []string, key string, original, modified, live []interface{}) ([]interface{}, bool, error) {
+	originalIndex, ok := indexList(original, key)
+	if !ok {
+		return nil, false, nil
+	}
+	modifiedIndex, ok := indexList(modified, key)
+	if !ok {
+		return nil, false, nil
+	}
+	liveIndex, ok := indexList(live, key)
+	if !ok {
+		return nil, false, nil
+	}
+	var order []string
+	seen := map[string]struct{}{}
+	for _, items := range [][]interface{}{live, modified} {
+		for _, item := range items {
+			k := listElementKey(item, key)
+			if _, found := seen[k]; !found {
+				seen[k] = struct{}{}
+				order = append(order, k)
+			}
+		}
+	}
+	for _, item := range original {
+		k := listElementKey(item, key)
+		if _, found := seen[k]; !found {
+			seen[k] = struct{}{}
+			order = append(order, k)
+		}
+	}
+	merged := make([]interface{}, 0, len(order))
+	last := len(path) - 1
+	for _, k := range order {
+		elemPath := append(path[:last:last], fmt.Sprintf("%s[%s=%s]", path[last], key, k))
+		o, oExists := originalIndex[k]
+		mod, mExists := modifiedIndex[k]
+		l, lExists := liveIndex[k]
+		value, exists, err := m.merge(elemPath, fields,
+			field{value: o, exists: oExists}, field{value: mod, exists: mExists}, field{value: l, exists: lExists})
+		if err != nil {
+			return nil, true, err
+		}
+		if exists {
+			merged = append(merged, value)
+		}
+	}
+	return merged, true, nil
+}
+
+func indexList(items []interface{}, key string) (map[string]interface{}, bool) {
+	index := map[string]interface{}{}
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if _, found := obj[key]; !found {
+			return nil, false
+		}
+		k := listElementKey(item, key)
+		if _, found := index[k]; found {
+			return nil, false
+		}
+		index[k] = item
+	}
+	return index, true
+}
+
+func listElementKey(item interface{}, key string) string {
+	return fmt.Sprint(item.(map[string]interface{})[key])
+}
+
+// resolve records the conflict and resolves it by the configured strategy
+func (m *merger) resolve(conflict Conflict, modified, live field) (interface{}, bool, error) {
+	m.conflicts = append(m.conflicts, conflict)
+	if m.cfg.resolver != nil {
+		return m.cfg.resolver(conflict)
+	}
+	switch m.cfg.strategy {
+	case ConflictStrategyPreferModified:
+		return modified.get()
+	case ConflictStrategyError, ConflictStrategyPreferLive:
+		return live.get()
+	default:
+		return nil, false, fmt.Errorf("unknown conflict strategy %s", m.cfg.strategy)
+	}
+}
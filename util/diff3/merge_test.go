@@ -0,0 +1,257 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff3
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func parse(t *testing.T, s string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	require.NoError(t, yaml.Unmarshal([]byte(s), &obj.Object))
+	return obj
+}
+
+const original = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+  labels:
+    app: example
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+      - name: sidecar
+        image: sidecar:v1
+`
+
+const modified = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+  labels:
+    app: example
+    version: v2
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v2
+      - name: logger
+        image: logger:v1
+`
+
+const live = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+  resourceVersion: "10"
+  labels:
+    app: example
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: injected
+        image: injected:v1
+      - name: app
+        image: app:v1
+        env:
+        - name: KEY
+          value: val
+      - name: sidecar
+        image: sidecar:v1
+`
+
+func TestMerge(t *testing.T) {
+	testcases := map[string]struct {
+		options   []MergeOption
+		expected  string
+		conflicts []string
+		err       bool
+	}{
+		"error": {
+			options:   []MergeOption{WithMergeKeys{"spec.template.spec.containers": "name"}},
+			conflicts: []string{"spec.replicas"},
+			err:       true,
+		},
+		"prefer-modified": {
+			options: []MergeOption{ConflictStrategyPreferModified, WithMergeKeys{"spec.template.spec.containers": "name"}},
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+  resourceVersion: "10"
+  labels:
+    app: example
+    version: v2
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: injected
+        image: injected:v1
+      - name: app
+        image: app:v2
+        env:
+        - name: KEY
+          value: val
+      - name: logger
+        image: logger:v1
+`,
+			conflicts: []string{"spec.replicas"},
+		},
+		"prefer-live-without-merge-keys": {
+			options: []MergeOption{ConflictStrategyPreferLive, WithConflictAnnotation(DefaultConflictAnnotation)},
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+  resourceVersion: "10"
+  labels:
+    app: example
+    version: v2
+  annotations:
+    diff3.oam.dev/conflicts: '["spec.replicas","spec.template.spec.containers"]'
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: injected
+        image: injected:v1
+      - name: app
+        image: app:v1
+        env:
+        - name: KEY
+          value: val
+      - name: sidecar
+        image: sidecar:v1
+`,
+			conflicts: []string{"spec.replicas", "spec.template.spec.containers"},
+		},
+		"resolver": {
+			options: []MergeOption{ConflictResolver(func(conflict Conflict) (interface{}, bool, error) {
+				return conflict.Modified.(float64) + conflict.Live.(float64), true, nil
+			}), WithMergeKeys{"spec.template.spec.containers": "name"}},
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+  resourceVersion: "10"
+  labels:
+    app: example
+    version: v2
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - name: injected
+        image: injected:v1
+      - name: app
+        image: app:v2
+        env:
+        - name: KEY
+          value: val
+      - name: logger
+        image: logger:v1
+`,
+			conflicts: []string{"spec.replicas"},
+		},
+	}
+	for name, tt := range testcases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			o, m, l := parse(t, original), parse(t, modified), parse(t, live)
+			merged, conflicts, err := Merge(o, m, l, tt.options...)
+			var paths []string
+			for _, c := range conflicts {
+				paths = append(paths, c.Path)
+			}
+			r.ElementsMatch(tt.conflicts, paths)
+			if tt.err {
+				conflictErr := &ConflictError{}
+				r.True(errors.As(err, &conflictErr))
+				r.Equal(len(tt.conflicts), len(conflictErr.Conflicts))
+				return
+			}
+			r.NoError(err)
+			r.Equal(parse(t, tt.expected).Object, merged.Object)
+			r.Equal(parse(t, live).Object, l.Object)
+		})
+	}
+}
+
+func TestMergeListElementConflict(t *testing.T) {
+	r := require.New(t)
+	o := map[string]interface{}{"items": []interface{}{map[string]interface{}{"name": "a", "value": "1"}}}
+	m := map[string]interface{}{"items": []interface{}{map[string]interface{}{"name": "a", "value": "2"}}}
+	l := map[string]interface{}{"items": []interface{}{map[string]interface{}{"name": "a", "value": "3"}}}
+	merged, conflicts, err := MergeObjects(o, m, l, WithMergeKeys{"items": "name"}, ConflictStrategyPreferLive)
+	r.NoError(err)
+	r.Equal(l, merged)
+	r.Equal([]Conflict{{Path: "items[name=a].value", Original: "1", Modified: "2", Live: "3"}}, conflicts)
+
+	_, _, err = MergeObjects(o, m, l, ConflictStrategy("Unknown"))
+	r.Error(err)
+
+	merged, conflicts, err = MergeObjects(nil, m, nil)
+	r.NoError(err)
+	r.Empty(conflicts)
+	r.Equal(m, merged)
+}
+
+func TestMergeConflictOrder(t *testing.T) {
+	r := require.New(t)
+	o, m, l := map[string]interface{}{}, map[string]interface{}{}, map[string]interface{}{}
+	for _, k := range []string{"e", "b", "d", "a", "c"} {
+		o[k], m[k], l[k] = "o", "m", "l"
+	}
+	original := &unstructured.Unstructured{Object: o}
+	modified := &unstructured.Unstructured{Object: m}
+	live := &unstructured.Unstructured{Object: l}
+	for i := 0; i < 10; i++ {
+		merged, conflicts, err := Merge(original, modified, live, ConflictStrategyPreferLive, WithConflictAnnotation(DefaultConflictAnnotation))
+		r.NoError(err)
+		var paths []string
+		for _, c := range conflicts {
+			paths = append(paths, c.Path)
+		}
+		r.Equal([]string{"a", "b", "c", "d", "e"}, paths)
+		r.Equal(`["a","b","c","d","e"]`, merged.GetAnnotations()[DefaultConflictAnnotation])
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff3
+
+// ConflictStrategy the strategy to resolve the conflict where both the
+// modified and the live object changed the same field differently
+type ConflictStrategy string
+
+const (
+	// ConflictStrategyError keeps the live value and fails the merge with
+	// *ConflictError
+	ConflictStrategyError ConflictStrategy = "Error"
+	// ConflictStrategyPreferModified takes the value from the modified object
+	ConflictStrategyPreferModified ConflictStrategy = "PreferModified"
+	// ConflictStrategyPreferLive takes the value from the live object
+	ConflictStrategyPreferLive ConflictStrategy = "PreferLive"
+)
+
+// ConflictResolver resolves the conflict by returning the merged value. If
+// the returned exists is false, the field will be removed.
+type ConflictResolver func(conflict Conflict) (value interface{}, exists bool, err error)
+
+// mergeConfig the config for three-way merge
+type mergeConfig struct {
+	strategy   ConflictStrategy
+	resolver   ConflictResolver
+	mergeKeys  map[string]string
+	annotation string
+}
+
+func newMergeConfig(options ...MergeOption) mergeConfig {
+	cfg := mergeConfig{
+		strategy:  ConflictStrategyError,
+		mergeKeys: map[string]string{},
+	}
+	for _, op := range options {
+		op.ApplyToMerge(&cfg)
+	}
+	return cfg
+}
+
+// MergeOption the option for three-way merge
+type MergeOption interface {
+	ApplyToMerge(*mergeConfig)
+}
+
+// ApplyToMerge .
+func (op ConflictStrategy) ApplyToMerge(cfg *mergeConfig) {
+	cfg.strategy = op
+}
+
+// ApplyToMerge .
+func (op ConflictResolver) ApplyToMerge(cfg *mergeConfig) {
+	cfg.resolver = op
+}
+
+// WithMergeKeys set the merge keys for lists, where the key is the path of
+// the list field like spec.template.spec.containers and the value is the
+// field identifying the element like name. Lists without merge key are
+// merged as a whole.
+type WithMergeKeys map[string]string
+
+// ApplyToMerge .
+func (op WithMergeKeys) ApplyToMerge(cfg *mergeConfig) {
+	for path, key := range op {
+		cfg.mergeKeys[path] = key
+	}
+}
+
+// WithConflictAnnotation record the paths of the conflicts in the given
+// annotation of the merged object
+type WithConflictAnnotation string
+
+// ApplyToMerge .
+func (op WithConflictAnnotation) ApplyToMerge(cfg *mergeConfig) {
+	cfg.annotation = string(op)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventbus
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Bus holds named topics so that publishers and subscribers in different
+// components can find the same topic without sharing the instance directly
+type Bus struct {
+	mu      sync.Mutex
+	options []TopicOption
+	topics  map[string]interface{ Close() }
+}
+
+// New create a new Bus. The options will be used to create topics on the bus.
+func New(options ...TopicOption) *Bus {
+	return &Bus{
+		options: options,
+		topics:  map[string]interface{ Close() }{},
+	}
+}
+
+// TopicOf returns the topic with the given name on the bus. The topic will be
+// created if not exists. If the topic exists but carries another event type,
+// an error will be returned.
+func TopicOf[T any](bus *Bus, name string) (*Topic[T], error) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if t, found := bus.topics[name]; found {
+		topic, ok := t.(*Topic[T])
+		if !ok {
+			return nil, fmt.Errorf("topic %s exists with event type mismatch, expect %T", name, t)
+		}
+		return topic, nil
+	}
+	topic := NewTopic[T](name, bus.options...)
+	bus.topics[name] = topic
+	return topic, nil
+}
+
+// Close closes all topics on the bus
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for name, topic := range b.topics {
+		topic.Close()
+		delete(b.topics, name)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventbus_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/eventbus"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []int
+}
+
+func (r *recorder) handle(ctx context.Context, event int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recorder) get() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int{}, r.events...)
+}
+
+func TestTopic(t *testing.T) {
+	ctx := context.Background()
+	topic := eventbus.NewTopic[int]("test", eventbus.WithReplay(2), eventbus.WithRetryInterval(time.Millisecond))
+	require.Equal(t, "test", topic.Name())
+	for i := 0; i < 3; i++ {
+		require.NoError(t, topic.Publish(ctx, i))
+	}
+
+	// late subscriber receives the replayed events first
+	r := &recorder{}
+	unsubscribe := topic.Subscribe(r.handle)
+	require.NoError(t, topic.Publish(ctx, 3))
+	require.Eventually(t, func() bool { return len(r.get()) == 3 }, time.Second, time.Millisecond)
+	require.Equal(t, []int{1, 2, 3}, r.get())
+
+	// failed events are redelivered
+	failures := 0
+	retried := &recorder{}
+	topic.Subscribe(func(ctx context.Context, event int) error {
+		if failures < 2 {
+			failures++
+			return fmt.Errorf("injected")
+		}
+		return retried.handle(ctx, event)
+	})
+	require.Eventually(t, func() bool { return len(retried.get()) == 2 }, time.Second, time.Millisecond)
+	require.Equal(t, []int{2, 3}, retried.get())
+
+	unsubscribe()
+	require.NoError(t, topic.Publish(ctx, 4))
+	require.Eventually(t, func() bool { return len(retried.get()) == 3 }, time.Second, time.Millisecond)
+	require.Equal(t, []int{1, 2, 3}, r.get())
+
+	topic.Close()
+	require.ErrorIs(t, topic.Publish(ctx, 5), eventbus.ErrTopicClosed)
+}
+
+func TestTopicBackpressure(t *testing.T) {
+	topic := eventbus.NewTopic[int]("test", eventbus.WithBufferSize(1))
+	defer topic.Close()
+	block := make(chan struct{})
+	defer close(block)
+	topic.Subscribe(func(ctx context.Context, event int) error {
+		<-block
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var err error
+	for i := 0; i < 3 && err == nil; i++ {
+		err = topic.Publish(ctx, i)
+	}
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTopicNegativeBufferSize(t *testing.T) {
+	topic := eventbus.NewTopic[int]("test", eventbus.WithBufferSize(-1))
+	r := &recorder{}
+	defer topic.Subscribe(r.handle)()
+	require.NoError(t, topic.Publish(context.Background(), 1))
+	require.Eventually(t, func() bool { return len(r.get()) == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestTopicHandlerFailure(t *testing.T) {
+	// panics are recovered and the event is redelivered
+	topic := eventbus.NewTopic[int]("test", eventbus.WithRetryInterval(10*time.Millisecond))
+	defer topic.Close()
+	var mu sync.Mutex
+	calls := 0
+	r := &recorder{}
+	topic.Subscribe(func(ctx context.Context, event int) error {
+		mu.Lock()
+		calls++
+		first := calls == 1
+		mu.Unlock()
+		if first {
+			panic("boom")
+		}
+		return r.handle(ctx, event)
+	})
+	require.NoError(t, topic.Publish(context.Background(), 1))
+	require.Eventually(t, func() bool { return len(r.get()) == 1 }, time.Second, 10*time.Millisecond)
+
+	// non-positive retry interval does not redeliver in a busy loop
+	topic = eventbus.NewTopic[int]("test", eventbus.WithRetryInterval(0))
+	defer topic.Close()
+	failures := 0
+	topic.Subscribe(func(ctx context.Context, event int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		failures++
+		return fmt.Errorf("failed")
+	})
+	require.NoError(t, topic.Publish(context.Background(), 1))
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 1, failures)
+}
+
+func TestBus(t *testing.T) {
+	bus := eventbus.New()
+	defer bus.Close()
+	a, err := eventbus.TopicOf[string](bus, "a")
+	require.NoError(t, err)
+	_a, err := eventbus.TopicOf[string](bus, "a")
+	require.NoError(t, err)
+	require.Same(t, a, _a)
+	_, err = eventbus.TopicOf[int](bus, "a")
+	require.Error(t, err)
+}
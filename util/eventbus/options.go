@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventbus
+
+import "time"
+
+const (
+	// DefaultBufferSize the default size of the event buffer for each subscriber
+	DefaultBufferSize = 64
+	// DefaultRetryInterval the default interval for redelivering failed events
+	DefaultRetryInterval = time.Second
+)
+
+// topicConfig the config for creating topic
+type topicConfig struct {
+	bufferSize    int
+	replay        int
+	retryInterval time.Duration
+}
+
+func newTopicConfig(options ...TopicOption) topicConfig {
+	cfg := topicConfig{
+		bufferSize:    DefaultBufferSize,
+		retryInterval: DefaultRetryInterval,
+	}
+	for _, op := range options {
+		op.ApplyToTopic(&cfg)
+	}
+	return cfg
+}
+
+// TopicOption the option for creating topic
+type TopicOption interface {
+	ApplyToTopic(*topicConfig)
+}
+
+// WithBufferSize set the number of events buffered for each subscriber.
+// Publishing blocks when the buffer of any subscriber is full. Negative
+// sizes are treated as 0, which means unbuffered.
+type WithBufferSize int
+
+// ApplyToTopic .
+func (op WithBufferSize) ApplyToTopic(cfg *topicConfig) {
+	cfg.bufferSize = int(op)
+	if cfg.bufferSize < 0 {
+		cfg.bufferSize = 0
+	}
+}
+
+// WithReplay set the number of latest events retained by the topic and
+// replayed to subscribers joining later
+type WithReplay int
+
+// ApplyToTopic .
+func (op WithReplay) ApplyToTopic(cfg *topicConfig) {
+	cfg.replay = int(op)
+}
+
+// WithRetryInterval set the interval for redelivering events that the
+// handler failed to handle. Non-positive intervals fall back to
+// DefaultRetryInterval.
+type WithRetryInterval time.Duration
+
+// ApplyToTopic .
+func (op WithRetryInterval) ApplyToTopic(cfg *topicConfig) {
+	cfg.retryInterval = time.Duration(op)
+	if cfg.retryInterval <= 0 {
+		cfg.retryInterval = DefaultRetryInterval
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// ErrTopicClosed is returned when publishing to a closed topic
+var ErrTopicClosed = errors.New("topic closed")
+
+// Handler handles the event delivered by the topic. If error returned or the
+// handler panics, the event will be redelivered to the handler after the
+// retry interval.
+type Handler[T any] func(ctx context.Context, event T) error
+
+// Topic a typed channel that delivers published events to all subscribers
+type Topic[T any] struct {
+	name string
+	cfg  topicConfig
+
+	mu          sync.Mutex
+	closed      bool
+	nextID      uint64
+	history     []T
+	subscribers map[uint64]*subscription[T]
+}
+
+// NewTopic create a new topic with the given name
+func NewTopic[T any](name string, options ...TopicOption) *Topic[T] {
+	cfg := newTopicConfig(options...)
+	return &Topic[T]{
+		name:        name,
+		cfg:         cfg,
+		subscribers: map[uint64]*subscription[T]{},
+	}
+}
+
+// Name returns the name of the topic
+func (t *Topic[T]) Name() string {
+	return t.name
+}
+
+// Publish sends the event to all subscribers. If the buffer of any subscriber
+// is full, Publish blocks until the buffer has room or the context is done.
+// If the context is done halfway, the event is not withdrawn from the
+// subscribers that have already received it.
+func (t *Topic[T]) Publish(ctx context.Context, event T) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return ErrTopicClosed
+	}
+	if t.cfg.replay > 0 {
+		t.history = append(t.history, event)
+		if len(t.history) > t.cfg.replay {
+			t.history = t.history[len(t.history)-t.cfg.replay:]
+		}
+	}
+	subs := make([]*subscription[T], 0, len(t.subscribers))
+	for _, sub := range t.subscribers {
+		subs = append(subs, sub)
+	}
+	t.mu.Unlock()
+
+	for _, sub := range subs {
+		select {
+		case sub.events <- event:
+		case <-sub.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Subscribe registers the handler to the topic. If replay is enabled for the
+// topic, the retained events will be delivered to the handler first. The
+// returned function cancels the subscription.
+func (t *Topic[T]) Subscribe(handler Handler[T]) (unsubscribe func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return func() {}
+	}
+	sub := &subscription[T]{
+		topic:         t.name,
+		handler:       handler,
+		events:        make(chan T, t.cfg.bufferSize),
+		done:          make(chan struct{}),
+		retryInterval: t.cfg.retryInterval,
+	}
+	replay := make([]T, len(t.history))
+	copy(replay, t.history)
+	id := t.nextID
+	t.nextID++
+	t.subscribers[id] = sub
+	go sub.run(replay)
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if _, found := t.subscribers[id]; found {
+			delete(t.subscribers, id)
+			sub.stop()
+		}
+	}
+}
+
+// Close stops all the subscriptions of the topic. Events remaining in the
+// subscriber buffers will be dropped.
+func (t *Topic[T]) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+	for id, sub := range t.subscribers {
+		delete(t.subscribers, id)
+		sub.stop()
+	}
+}
+
+// subscription delivers events to the handler in a dedicated goroutine
+type subscription[T any] struct {
+	topic         string
+	handler       Handler[T]
+	events        chan T
+	done          chan struct{}
+	retryInterval time.Duration
+	once          sync.Once
+}
+
+func (s *subscription[T]) stop() {
+	s.once.Do(func() { close(s.done) })
+}
+
+func (s *subscription[T]) run(replay []T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.done
+		cancel()
+	}()
+	for _, event := range replay {
+		if !s.deliver(ctx, event) {
+			return
+		}
+	}
+	for {
+		select {
+		case event := <-s.events:
+			if !s.deliver(ctx, event) {
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// deliver calls the handler until it succeeds. It returns false if the
+// subscription is stopped before the event is handled.
+func (s *subscription[T]) deliver(ctx context.Context, event T) bool {
+	for {
+		if err := s.handle(ctx, event); err == nil {
+			return true
+		}
+		select {
+		case <-time.After(s.retryInterval):
+		case <-s.done:
+			return false
+		}
+	}
+}
+
+// handle calls the handler and converts the panic into error
+func (s *subscription[T]) handle(ctx context.Context, event T) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			klog.Errorf("handler of topic %s panics: %v\n%s", s.topic, r, debug.Stack())
+			err = fmt.Errorf("handler panics: %v", r)
+		}
+	}()
+	return s.handler(ctx, event)
+}
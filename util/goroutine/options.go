@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goroutine
+
+import "time"
+
+const (
+	// DefaultRestartBackoff the default initial delay for restarting tasks
+	DefaultRestartBackoff = time.Second
+	// DefaultMaxRestartBackoff the default max delay for restarting tasks
+	DefaultMaxRestartBackoff = 5 * time.Minute
+)
+
+// RestartPolicy the policy for restarting the task after it returns
+type RestartPolicy string
+
+const (
+	// RestartAlways restarts the task whenever it returns, until the
+	// supervisor stops
+	RestartAlways RestartPolicy = "Always"
+	// RestartOnFailure restarts the task if it returns error or panics
+	RestartOnFailure RestartPolicy = "OnFailure"
+	// RestartNever never restarts the task
+	RestartNever RestartPolicy = "Never"
+)
+
+// taskConfig the config for running task
+type taskConfig struct {
+	restartPolicy   RestartPolicy
+	backoff         time.Duration
+	maxBackoff      time.Duration
+	livenessTimeout time.Duration
+}
+
+func newTaskConfig(options ...TaskOption) taskConfig {
+	cfg := taskConfig{
+		restartPolicy: RestartOnFailure,
+		backoff:       DefaultRestartBackoff,
+		maxBackoff:    DefaultMaxRestartBackoff,
+	}
+	for _, op := range options {
+		op.ApplyToTask(&cfg)
+	}
+	return cfg
+}
+
+// TaskOption the option for running task
+type TaskOption interface {
+	ApplyToTask(*taskConfig)
+}
+
+// ApplyToTask .
+func (op RestartPolicy) ApplyToTask(cfg *taskConfig) {
+	cfg.restartPolicy = op
+}
+
+// WithRestartBackoff set the delay for restarting the task. The delay starts
+// from Initial and doubles after each consecutive failure, up to Max. It is
+// reset once the task keeps running longer than Max.
+type WithRestartBackoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// ApplyToTask .
+func (op WithRestartBackoff) ApplyToTask(cfg *taskConfig) {
+	if op.Initial > 0 {
+		cfg.backoff = op.Initial
+	}
+	if op.Max > 0 {
+		cfg.maxBackoff = op.Max
+	}
+}
+
+// WithLivenessTimeout requires the task to call Heartbeat within the timeout,
+// otherwise the task is reported as not alive by the supervisor
+type WithLivenessTimeout time.Duration
+
+// ApplyToTask .
+func (op WithLivenessTimeout) ApplyToTask(cfg *taskConfig) {
+	cfg.livenessTimeout = time.Duration(op)
+}
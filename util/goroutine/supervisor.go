@@ -0,0 +1,263 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goroutine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// taskRestartCounter counts the restarts of the supervised tasks
+	taskRestartCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubevela_supervised_task_restarts_total",
+		Help: "number of restarts of the supervised tasks",
+	}, []string{"supervisor", "task"})
+	// taskPanicCounter counts the panics of the supervised tasks
+	taskPanicCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubevela_supervised_task_panics_total",
+		Help: "number of panics recovered from the supervised tasks",
+	}, []string{"supervisor", "task"})
+	// taskRunningGauge records whether the supervised tasks are running
+	taskRunningGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubevela_supervised_task_running",
+		Help: "whether the supervised task is running",
+	}, []string{"supervisor", "task"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(taskRestartCounter, taskPanicCounter, taskRunningGauge)
+}
+
+// Task the long-running function supervised. It should return when the
+// context is done.
+type Task func(ctx context.Context) error
+
+// PanicError the error for the recovered panic of the task
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error .
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// TaskStatus the status of the supervised task
+type TaskStatus struct {
+	Name     string
+	Running  bool
+	Restarts int
+	// LastError the error of the last run, including the recovered panic
+	LastError error
+	// LastHeartbeat the time of the latest heartbeat, or the time the task
+	// started if it has not reported any heartbeat
+	LastHeartbeat time.Time
+	// Finished the task returns and will not be restarted
+	Finished bool
+}
+
+type taskState struct {
+	name   string
+	task   Task
+	cfg    taskConfig
+	status TaskStatus
+}
+
+// Supervisor runs the named tasks in the background, recovers their panics
+// and restarts them by the restart policies
+type Supervisor struct {
+	name string
+
+	mu      sync.Mutex
+	tasks   map[string]*taskState
+	ctx     context.Context
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewSupervisor create a Supervisor. The name is used as the label of metrics.
+func NewSupervisor(name string) *Supervisor {
+	return &Supervisor{name: name, tasks: map[string]*taskState{}}
+}
+
+// Go adds the task to the supervisor. The task is started immediately if the
+// supervisor is running, otherwise it is started along with the supervisor.
+// Task names must be unique in the supervisor.
+func (s *Supervisor) Go(name string, task Task, options ...TaskOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.tasks[name]; found {
+		return fmt.Errorf("task %s already exists", name)
+	}
+	state := &taskState{name: name, task: task, cfg: newTaskConfig(options...), status: TaskStatus{Name: name}}
+	s.tasks[name] = state
+	if s.started {
+		s.run(state)
+	}
+	return nil
+}
+
+// Start starts all the tasks and blocks until the context is done and all the
+// tasks return. It can be added to the controller manager as a Runnable.
+func (s *Supervisor) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return fmt.Errorf("supervisor %s already started", s.name)
+	}
+	s.ctx, s.started = ctx, true
+	for _, state := range s.tasks {
+		s.run(state)
+	}
+	s.mu.Unlock()
+	<-ctx.Done()
+	s.wg.Wait()
+	return nil
+}
+
+// run starts the task in background, must be called with the lock held
+func (s *Supervisor) run(state *taskState) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ctx := context.WithValue(s.ctx, heartbeatKey, func() { s.heartbeat(state) })
+		backoff := state.cfg.backoff
+		for {
+			startedAt := time.Now()
+			s.update(state, func(status *TaskStatus) {
+				status.Running, status.LastHeartbeat = true, startedAt
+			})
+			taskRunningGauge.WithLabelValues(s.name, state.name).Set(1)
+			err := s.call(ctx, state)
+			taskRunningGauge.WithLabelValues(s.name, state.name).Set(0)
+			restart := ctx.Err() == nil && (state.cfg.restartPolicy == RestartAlways ||
+				state.cfg.restartPolicy == RestartOnFailure && err != nil)
+			s.update(state, func(status *TaskStatus) {
+				status.Running, status.LastError, status.Finished = false, err, !restart
+			})
+			if err != nil && ctx.Err() == nil {
+				klog.Errorf("supervised task %s/%s failed: %s", s.name, state.name, err.Error())
+			}
+			if !restart {
+				return
+			}
+			if time.Since(startedAt) > state.cfg.maxBackoff {
+				backoff = state.cfg.backoff
+			}
+			select {
+			case <-ctx.Done():
+				s.update(state, func(status *TaskStatus) { status.Finished = true })
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > state.cfg.maxBackoff {
+				backoff = state.cfg.maxBackoff
+			}
+			taskRestartCounter.WithLabelValues(s.name, state.name).Inc()
+			s.update(state, func(status *TaskStatus) { status.Restarts++ })
+		}
+	}()
+}
+
+// call runs the task once and converts the panic into *PanicError
+func (s *Supervisor) call(ctx context.Context, state *taskState) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			taskPanicCounter.WithLabelValues(s.name, state.name).Inc()
+			panicErr := &PanicError{Value: r, Stack: debug.Stack()}
+			klog.Errorf("supervised task %s/%s panics: %v\n%s", s.name, state.name, r, panicErr.Stack)
+			err = panicErr
+		}
+	}()
+	return state.task(ctx)
+}
+
+func (s *Supervisor) update(state *taskState, fn func(status *TaskStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&state.status)
+}
+
+func (s *Supervisor) heartbeat(state *taskState) {
+	s.update(state, func(status *TaskStatus) { status.LastHeartbeat = time.Now() })
+}
+
+// Status returns the status of all the tasks sorted by name
+func (s *Supervisor) Status() []TaskStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]TaskStatus, 0, len(s.tasks))
+	for _, state := range s.tasks {
+		statuses = append(statuses, state.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Check reports error if any task is waiting for restart, finished with error,
+// or has not reported heartbeat within its liveness timeout. It can be used
+// as the healthz or readyz checker.
+func (s *Supervisor) Check(_ *http.Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started || s.ctx.Err() != nil {
+		return nil
+	}
+	now := time.Now()
+	var msgs []string
+	for _, state := range s.tasks {
+		status := state.status
+		switch {
+		case !status.Running && status.LastError != nil:
+			msgs = append(msgs, fmt.Sprintf("%s failed: %s", status.Name, status.LastError.Error()))
+		case status.Running && state.cfg.livenessTimeout > 0 && now.Sub(status.LastHeartbeat) > state.cfg.livenessTimeout:
+			msgs = append(msgs, fmt.Sprintf("%s has no heartbeat since %s", status.Name, status.LastHeartbeat.Format(time.RFC3339)))
+		}
+	}
+	if len(msgs) > 0 {
+		sort.Strings(msgs)
+		return fmt.Errorf("unhealthy tasks in supervisor %s: [%s]", s.name, strings.Join(msgs, ", "))
+	}
+	return nil
+}
+
+type key int
+
+const (
+	// heartbeatKey is the context key for reporting heartbeat
+	heartbeatKey key = iota
+)
+
+// Heartbeat reports the supervised task in the context is alive. It does
+// nothing if the context is not from a supervised task.
+func Heartbeat(ctx context.Context) {
+	if fn, ok := ctx.Value(heartbeatKey).(func()); ok {
+		fn()
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goroutine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupervisor(t *testing.T) {
+	r := require.New(t)
+	s := NewSupervisor("test")
+	backoff := WithRestartBackoff{Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond}
+
+	var panics int32
+	r.NoError(s.Go("panic", func(ctx context.Context) error {
+		if atomic.AddInt32(&panics, 1) <= 2 {
+			panic("boom")
+		}
+		<-ctx.Done()
+		return nil
+	}, backoff))
+	r.NoError(s.Go("once", func(ctx context.Context) error {
+		return errors.New("failed")
+	}, RestartNever))
+	var runs int32
+	r.NoError(s.Go("always", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}, RestartAlways, backoff))
+	r.Error(s.Go("once", func(ctx context.Context) error { return nil }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.NoError(s.Start(ctx))
+		close(done)
+	}()
+	r.Eventually(func() bool { return atomic.LoadInt32(&runs) >= 3 }, 5*time.Second, 10*time.Millisecond)
+	r.Eventually(func() bool {
+		status := s.Status()
+		return status[2].Running && status[2].Restarts == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	status := s.Status()
+	r.Equal("once", status[1].Name)
+	r.True(status[1].Finished)
+	r.Equal("failed", status[1].LastError.Error())
+	panicErr := &PanicError{}
+	r.True(errors.As(status[2].LastError, &panicErr))
+	r.Equal("boom", panicErr.Value)
+	err := s.Check(nil)
+	r.Error(err)
+	r.Contains(err.Error(), "once failed")
+	r.NotContains(err.Error(), "panic")
+
+	// tasks added after started run immediately and report liveness
+	var beats int32
+	r.NoError(s.Go("live", func(ctx context.Context) error {
+		for atomic.AddInt32(&beats, 1) <= 3 {
+			Heartbeat(ctx)
+			time.Sleep(10 * time.Millisecond)
+		}
+		<-ctx.Done()
+		return nil
+	}, WithLivenessTimeout(100*time.Millisecond)))
+	r.Eventually(func() bool {
+		err := s.Check(nil)
+		return err != nil && strings.Contains(err.Error(), "live has no heartbeat")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+	r.NoError(s.Check(nil))
+	r.Error(s.Start(context.Background()))
+	for _, status := range s.Status() {
+		r.False(status.Running)
+		r.True(status.Finished)
+	}
+	Heartbeat(context.Background())
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"time"
+
+	"github.com/kubevela/pkg/util/profiling"
+)
+
+const (
+	// DefaultShutdownTimeout the default timeout for draining connections
+	// during graceful shutdown
+	DefaultShutdownTimeout = 30 * time.Second
+	// DefaultReadHeaderTimeout the default timeout for reading request headers
+	DefaultReadHeaderTimeout = 10 * time.Second
+	// DefaultIdleTimeout the default timeout for idle keep-alive connections
+	DefaultIdleTimeout = 120 * time.Second
+)
+
+// ServerOption the option for creating Server
+type ServerOption interface {
+	ApplyToServer(*Server)
+}
+
+// WithTLS serve https with the certificate and key files. The files are
+// reloaded when changed, so rotated certificates take effect without restart.
+type WithTLS struct {
+	CertFile string
+	KeyFile  string
+}
+
+// ApplyToServer .
+func (op WithTLS) ApplyToServer(s *Server) {
+	s.certFile, s.keyFile = op.CertFile, op.KeyFile
+}
+
+// WithPprof serve the pprof endpoints under /debug/pprof/ for requests
+// allowed by the authorizer. If the authorizer is nil, only loopback requests
+// are allowed. The pprof endpoints are not served without this option.
+type WithPprof struct {
+	Authorizer profiling.Authorizer
+}
+
+// ApplyToServer .
+func (op WithPprof) ApplyToServer(s *Server) {
+	s.pprof = true
+	s.pprofAuthorizer = op.Authorizer
+}
+
+// WithMiddleware wraps all the endpoints with the middleware. Middlewares are
+// applied in the order of the options, the first one is the outermost.
+type WithMiddleware Middleware
+
+// ApplyToServer .
+func (op WithMiddleware) ApplyToServer(s *Server) {
+	s.middlewares = append(s.middlewares, Middleware(op))
+}
+
+// WithShutdownTimeout set the timeout for draining connections during
+// graceful shutdown
+type WithShutdownTimeout time.Duration
+
+// ApplyToServer .
+func (op WithShutdownTimeout) ApplyToServer(s *Server) {
+	s.shutdownTimeout = time.Duration(op)
+}
+
+// WithReadHeaderTimeout set the timeout for reading request headers
+type WithReadHeaderTimeout time.Duration
+
+// ApplyToServer .
+func (op WithReadHeaderTimeout) ApplyToServer(s *Server) {
+	s.readHeaderTimeout = time.Duration(op)
+}
+
+// WithShutdownDrainDelay keeps serving with the failing readiness check for
+// the delay before shutting down, so that the probes can observe it and stop
+// routing traffic. The delay should be longer than the readiness probe period.
+// There is no delay by default.
+type WithShutdownDrainDelay time.Duration
+
+// ApplyToServer .
+func (op WithShutdownDrainDelay) ApplyToServer(s *Server) {
+	if op < 0 {
+		op = 0
+	}
+	s.shutdownDrainDelay = time.Duration(op)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/kubevela/pkg/util/profiling"
+)
+
+// Server is the admin server exposing /healthz, /readyz, /metrics and
+// optionally /debug/pprof/. It can be added to the controller-runtime manager
+// as a Runnable.
+type Server struct {
+	addr               string
+	mux                *http.ServeMux
+	healthz            *healthz.Handler
+	readyz             *healthz.Handler
+	certFile           string
+	keyFile            string
+	pprof              bool
+	pprofAuthorizer    profiling.Authorizer
+	middlewares        []Middleware
+	shutdownTimeout    time.Duration
+	shutdownDrainDelay time.Duration
+	readHeaderTimeout  time.Duration
+
+	shuttingDown atomic.Value
+}
+
+// New create the admin server listening on addr
+func New(addr string, options ...ServerOption) *Server {
+	s := &Server{
+		addr:              addr,
+		mux:               http.NewServeMux(),
+		healthz:           &healthz.Handler{Checks: map[string]healthz.Checker{"ping": healthz.Ping}},
+		readyz:            &healthz.Handler{Checks: map[string]healthz.Checker{}},
+		shutdownTimeout:   DefaultShutdownTimeout,
+		readHeaderTimeout: DefaultReadHeaderTimeout,
+	}
+	s.shuttingDown.Store(false)
+	// fail the readiness check once shutdown starts, so traffic is drained
+	// during the drain delay
+	s.readyz.Checks["shutdown"] = func(*http.Request) error {
+		if s.shuttingDown.Load().(bool) {
+			return fmt.Errorf("server is shutting down")
+		}
+		return nil
+	}
+	for _, op := range options {
+		op.ApplyToServer(s)
+	}
+	s.mux.Handle("/healthz", http.StripPrefix("/healthz", s.healthz))
+	s.mux.Handle("/healthz/", http.StripPrefix("/healthz", s.healthz))
+	s.mux.Handle("/readyz", http.StripPrefix("/readyz", s.readyz))
+	s.mux.Handle("/readyz/", http.StripPrefix("/readyz", s.readyz))
+	s.mux.Handle("/metrics", promhttp.HandlerFor(ctrlmetrics.Registry, promhttp.HandlerOpts{}))
+	if s.pprof {
+		s.mux.Handle("/debug/pprof/", profiling.NewHandler(nil, s.pprofAuthorizer))
+	}
+	return s
+}
+
+// AddHealthzCheck adds the liveness check. It must be called before the
+// server starts.
+func (s *Server) AddHealthzCheck(name string, check healthz.Checker) {
+	s.healthz.Checks[name] = check
+}
+
+// AddReadyzCheck adds the readiness check. It must be called before the
+// server starts.
+func (s *Server) AddReadyzCheck(name string, check healthz.Checker) {
+	s.readyz.Checks[name] = check
+}
+
+// Handle registers the handler for the pattern. It must be called before the
+// server starts.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Handler returns the handler serving all the endpoints with middlewares
+func (s *Server) Handler() http.Handler {
+	var handler http.Handler = s.mux
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+	return Recover(handler)
+}
+
+// Start listens on the address and serves until the context is done
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves on the listener until the context is done, then shuts down
+// gracefully within the shutdown timeout. The readiness check fails during
+// the drain delay before the shutdown starts.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: s.readHeaderTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+	}
+	if s.certFile != "" {
+		reloader, err := newCertReloader(s.certFile, s.keyFile)
+		if err != nil {
+			_ = ln.Close()
+			return fmt.Errorf("failed to load certificate: %w", err)
+		}
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: reloader.GetCertificate}
+		ln = tls.NewListener(ln, server.TLSConfig)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(ln)
+	}()
+	klog.Infof("admin server listening on %s", ln.Addr().String())
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	s.shuttingDown.Store(true)
+	if s.shutdownDrainDelay > 0 {
+		klog.Infof("admin server draining for %s before shutdown", s.shutdownDrainDelay)
+		select {
+		case err := <-errCh:
+			return err
+		case <-time.After(s.shutdownDrainDelay):
+		}
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shutdown admin server: %w", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
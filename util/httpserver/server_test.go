@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/profiling"
+)
+
+func writeCert(t *testing.T, dir string, serial int64, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func startServer(t *testing.T, s *Server) (string, context.CancelFunc, chan error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Serve(ctx, ln) }()
+	return ln.Addr().String(), cancel, errCh
+}
+
+func get(t *testing.T, cli *http.Client, url string, header http.Header) (int, string) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header = header
+	resp, err := cli.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestServer(t *testing.T) {
+	r := require.New(t)
+	s := New("", WithPprof{}, WithMiddleware(Authorize(profiling.BearerToken("t0ken"), "/healthz", "/readyz")))
+	ready := fmt.Errorf("not ready")
+	s.AddReadyzCheck("cache", func(*http.Request) error { return ready })
+	s.Handle("/panic", http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("boom") }))
+	addr, cancel, errCh := startServer(t, s)
+	base := "http://" + addr
+	auth := http.Header{"Authorization": []string{"Bearer t0ken"}}
+	cli := &http.Client{}
+
+	code, _ := get(t, cli, base+"/healthz", nil)
+	r.Equal(http.StatusOK, code)
+	code, body := get(t, cli, base+"/readyz?verbose", nil)
+	r.Equal(http.StatusInternalServerError, code)
+	r.Contains(body, "cache")
+	ready = nil
+	code, _ = get(t, cli, base+"/readyz/cache", nil)
+	r.Equal(http.StatusOK, code)
+
+	code, _ = get(t, cli, base+"/metrics", nil)
+	r.Equal(http.StatusForbidden, code)
+	code, _ = get(t, cli, base+"/metrics", auth)
+	r.Equal(http.StatusOK, code)
+	code, _ = get(t, cli, base+"/debug/pprof/", auth)
+	r.Equal(http.StatusOK, code)
+	code, _ = get(t, cli, base+"/panic", auth)
+	r.Equal(http.StatusInternalServerError, code)
+
+	cancel()
+	r.NoError(<-errCh)
+}
+
+func TestServerShutdownDrainDelay(t *testing.T) {
+	r := require.New(t)
+	addr, cancel, errCh := startServer(t, New("", WithShutdownDrainDelay(500*time.Millisecond)))
+	cli := &http.Client{}
+	code, _ := get(t, cli, "http://"+addr+"/readyz", nil)
+	r.Equal(http.StatusOK, code)
+	cancel()
+	r.Eventually(func() bool {
+		code, _ = get(t, cli, "http://"+addr+"/readyz", nil)
+		return code == http.StatusInternalServerError
+	}, 400*time.Millisecond, 20*time.Millisecond)
+	code, _ = get(t, cli, "http://"+addr+"/healthz", nil)
+	r.Equal(http.StatusOK, code)
+	r.NoError(<-errCh)
+}
+
+func TestServerWithoutPprof(t *testing.T) {
+	addr, cancel, errCh := startServer(t, New(""))
+	code, _ := get(t, &http.Client{}, "http://"+addr+"/debug/pprof/", nil)
+	require.Equal(t, http.StatusNotFound, code)
+	cancel()
+	require.NoError(t, <-errCh)
+}
+
+func TestServerTLSReload(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+	now := time.Now()
+	writeCert(t, dir, 1, now.Add(-time.Minute))
+	s := New("", WithTLS{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key")}, WithShutdownTimeout(time.Second))
+	addr, cancel, errCh := startServer(t, s)
+	serial := func() int64 {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+		r.NoError(err)
+		defer func() { _ = conn.Close() }()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+	r.Equal(int64(1), serial())
+	writeCert(t, dir, 2, now)
+	r.Equal(int64(2), serial())
+	r.NoError(os.WriteFile(filepath.Join(dir, "tls.crt"), []byte("broken"), 0600))
+	r.NoError(os.Chtimes(filepath.Join(dir, "tls.crt"), now.Add(time.Minute), now.Add(time.Minute)))
+	r.Equal(int64(2), serial())
+	cancel()
+	r.NoError(<-errCh)
+
+	s = New("", WithTLS{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: filepath.Join(dir, "missing.key")})
+	_, cancel, errCh = startServer(t, s)
+	defer cancel()
+	r.Error(<-errCh)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// MaxLabelNameLength the max length of DNS-1123 label, used by names of
+	// resources like Service and Namespace
+	MaxLabelNameLength = validation.DNS1123LabelMaxLength
+	// MaxSubdomainNameLength the max length of DNS-1123 subdomain, used by
+	// names of most resources
+	MaxSubdomainNameLength = validation.DNS1123SubdomainMaxLength
+	// HashSuffixLength the length of the hash suffix appended to truncated names
+	HashSuffixLength = 8
+)
+
+// Hash returns the first n hex characters of the sha256 of the input
+func Hash(s string, n int) string {
+	sum := sha256.Sum256([]byte(s))
+	h := hex.EncodeToString(sum[:])
+	if n > 0 && n < len(h) {
+		return h[:n]
+	}
+	return h
+}
+
+// Sanitize converts the input into a DNS-1123 subdomain. Upper case letters
+// are lowered and invalid characters are replaced with '-'. Each segment
+// separated by '.' is trimmed of leading and trailing non-alphanumeric
+// characters, and empty segments are dropped.
+func Sanitize(s string) string {
+	b := []byte(strings.ToLower(s))
+	for i, c := range b {
+		if !isAlphanumeric(c) && c != '-' && c != '.' {
+			b[i] = '-'
+		}
+	}
+	var segments []string
+	for _, segment := range strings.Split(string(b), ".") {
+		segment = strings.TrimFunc(segment, func(r rune) bool {
+			return !isAlphanumeric(byte(r))
+		})
+		if len(segment) > 0 {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+func isAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
+
+// Truncate returns the name if it does not exceed maxLen. Otherwise, the
+// name is cut and suffixed by the hash of the full name, so that different
+// long names with the same prefix do not collide.
+func Truncate(name string, maxLen int) string {
+	if len(name) <= maxLen {
+		return name
+	}
+	suffix := Hash(name, HashSuffixLength)
+	if maxLen <= HashSuffixLength+1 {
+		return suffix[:maxLen]
+	}
+	prefix := strings.TrimRight(name[:maxLen-HashSuffixLength-1], "-.")
+	return prefix + "-" + suffix
+}
+
+// Join sanitizes the parts, joins them with '-' and truncates the result to
+// maxLen. The same parts always generate the same name. If no valid
+// character is left, the hash of the parts is used as the name.
+func Join(maxLen int, parts ...string) string {
+	var sanitized []string
+	for _, part := range parts {
+		if s := Sanitize(part); len(s) > 0 {
+			sanitized = append(sanitized, s)
+		}
+	}
+	if len(sanitized) == 0 {
+		return Truncate(Hash(strings.Join(parts, "-"), HashSuffixLength), maxLen)
+	}
+	return Truncate(strings.Join(sanitized, "-"), maxLen)
+}
+
+// LabelName generates a DNS-1123 label name from the parts, e.g. the name of
+// a Service derived from its parent
+func LabelName(parts ...string) string {
+	return Join(MaxLabelNameLength, strings.ReplaceAll(strings.Join(parts, "-"), ".", "-"))
+}
+
+// SubdomainName generates a DNS-1123 subdomain name from the parts, e.g. the
+// name of a ConfigMap derived from its parent
+func SubdomainName(parts ...string) string {
+	return Join(MaxSubdomainNameLength, parts...)
+}
+
+// ValidateLabelName checks if the name is a valid DNS-1123 label
+func ValidateLabelName(name string) error {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("invalid name %q: %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ValidateSubdomainName checks if the name is a valid DNS-1123 subdomain
+func ValidateSubdomainName(name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid name %q: %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idgen_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/idgen"
+)
+
+func TestSanitize(t *testing.T) {
+	testcases := map[string]string{
+		"Example_App":  "example-app",
+		"-a.b-":        "a.b",
+		"__x__":        "x",
+		"app@v1.2/web": "app-v1.2-web",
+		"a..b":         "a.b",
+		"a.-b":         "a.b",
+		"a-.b":         "a.b",
+		"a._.b-":       "a.b",
+		"..":           "",
+	}
+	for input, expected := range testcases {
+		t.Run(input, func(t *testing.T) {
+			require.Equal(t, expected, idgen.Sanitize(input))
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	require.Equal(t, "short", idgen.Truncate("short", 10))
+	long := strings.Repeat("a", 70)
+	truncated := idgen.Truncate(long, idgen.MaxLabelNameLength)
+	require.Equal(t, idgen.MaxLabelNameLength, len(truncated))
+	require.Equal(t, truncated, idgen.Truncate(long, idgen.MaxLabelNameLength))
+	require.NotEqual(t, truncated, idgen.Truncate(long+"b", idgen.MaxLabelNameLength))
+	require.Equal(t, 4, len(idgen.Truncate(long, 4)))
+	// trailing separators are trimmed before appending the hash
+	require.NotContains(t, idgen.Truncate(strings.Repeat("a", 53)+"--"+strings.Repeat("b", 20), 63), "---")
+}
+
+func TestNames(t *testing.T) {
+	name := idgen.LabelName("My.App", strings.Repeat("component", 10), "v1")
+	require.NoError(t, idgen.ValidateLabelName(name))
+	require.Equal(t, name, idgen.LabelName("My.App", strings.Repeat("component", 10), "v1"))
+
+	name = idgen.SubdomainName("my.app", "", "worker")
+	require.Equal(t, "my.app-worker", name)
+	require.NoError(t, idgen.ValidateSubdomainName(name))
+	require.Error(t, idgen.ValidateLabelName(name))
+	require.Error(t, idgen.ValidateSubdomainName("Invalid_Name"))
+	for _, parts := range [][]string{{"a..b"}, {"a.-b", "c"}, {"a-.b"}} {
+		require.NoError(t, idgen.ValidateSubdomainName(idgen.SubdomainName(parts...)))
+		require.NoError(t, idgen.ValidateLabelName(idgen.LabelName(parts...)))
+	}
+
+	// names with no valid character fall back to the hash
+	name = idgen.SubdomainName("__", "@@")
+	require.NoError(t, idgen.ValidateSubdomainName(name))
+	require.Equal(t, idgen.HashSuffixLength, len(name))
+	require.NotEqual(t, name, idgen.SubdomainName("__"))
+	require.NoError(t, idgen.ValidateLabelName(idgen.LabelName("...")))
+	require.Equal(t, 10, len(idgen.Hash("x", 10)))
+	require.Equal(t, 64, len(idgen.Hash("x", 0)))
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	velaruntime "github.com/kubevela/pkg/util/runtime"
+)
+
+// DefaultFieldManager the default field manager for server-side apply
+const DefaultFieldManager = "kubevela"
+
+// ApplyResultType the type of the result for applying one object
+type ApplyResultType string
+
+const (
+	// ApplyResultCreated the object did not exist and is created
+	ApplyResultCreated ApplyResultType = "created"
+	// ApplyResultUpdated the object existed and is changed
+	ApplyResultUpdated ApplyResultType = "updated"
+	// ApplyResultUnchanged the object existed and is not changed
+	ApplyResultUnchanged ApplyResultType = "unchanged"
+	// ApplyResultFailed the object failed to be applied
+	ApplyResultFailed ApplyResultType = "failed"
+	// ApplyResultSkipped the object is not applied because a previous object
+	// failed in ordered apply
+	ApplyResultSkipped ApplyResultType = "skipped"
+)
+
+// ApplyResult the result for applying one object
+type ApplyResult struct {
+	Object client.Object
+	Type   ApplyResultType
+	Err    error
+}
+
+// ApplyOptions the options for ApplyAll
+type ApplyOptions struct {
+	// FieldManager the field manager for server-side apply. If empty, the
+	// field manager in the context or DefaultFieldManager will be used.
+	FieldManager string
+	// Force take the ownership of conflicting fields
+	Force bool
+	// DryRun apply objects in server-side dry-run mode. It is also enabled if
+	// the dry-run mode is set in the context.
+	DryRun bool
+	// Concurrency the max number of objects applied at the same time. If not
+	// positive, all objects will be applied at the same time.
+	Concurrency int
+	// Ordered apply objects one by one in the given order. Once an object
+	// fails, the rest objects will be skipped.
+	Ordered bool
+	// Origin stamps the origin on the applied objects if set
+	Origin *Origin
+}
+
+// ApplyError the aggregated error for ApplyAll, it contains the results of
+// the failed and skipped objects
+type ApplyError struct {
+	Results []ApplyResult
+}
+
+// Error .
+func (e *ApplyError) Error() string {
+	var msgs []string
+	for _, r := range e.Results {
+		if r.Type == ApplyResultFailed {
+			msgs = append(msgs, fmt.Sprintf("%s %s: %s", GetKindForObject(r.Object, false), client.ObjectKeyFromObject(r.Object), r.Err.Error()))
+		}
+	}
+	return fmt.Sprintf("failed to apply %d objects: [%s]", len(msgs), strings.Join(msgs, ", "))
+}
+
+// Unwrap returns the errors of the failed objects
+func (e *ApplyError) Unwrap() []error {
+	var errs []error
+	for _, r := range e.Results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return errs
+}
+
+// Is checks if any error of the failed objects matches the target, so that
+// errors.Is works without the multi-error unwrapping of Go 1.20
+func (e *ApplyError) Is(target error) bool {
+	for _, err := range e.Unwrap() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error of the failed objects matching the target, so
+// that errors.As works without the multi-error unwrapping of Go 1.20
+func (e *ApplyError) As(target interface{}) bool {
+	for _, err := range e.Unwrap() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyAll applies the objects through server-side apply and returns the
+// results in the same order as the input objects. If any object fails, an
+// *ApplyError will be returned as well. Namespace-scoped objects without
+// namespace will be applied to the namespace set in the context. The input
+// objects are not changed, and the results hold the applied copies.
+func ApplyAll(ctx context.Context, cli client.Client, objs []client.Object, opts ApplyOptions) ([]ApplyResult, error) {
+	results := make([]ApplyResult, len(objs))
+	if opts.Origin != nil && opts.Origin.Controller == "" {
+		// the caller is not in the stack of the goroutines applying objects
+		origin := *opts.Origin
+		origin.Controller = velaruntime.GetControllerInCaller()
+		opts.Origin = &origin
+	}
+	if opts.Ordered {
+		failed := false
+		for i, obj := range objs {
+			if failed {
+				results[i] = ApplyResult{Object: obj, Type: ApplyResultSkipped}
+				continue
+			}
+			results[i] = applyObject(ctx, cli, obj, opts)
+			failed = results[i].Err != nil
+		}
+	} else {
+		concurrency := opts.Concurrency
+		if concurrency <= 0 || concurrency > len(objs) {
+			concurrency = len(objs)
+		}
+		sem := make(chan struct{}, concurrency)
+		wg := sync.WaitGroup{}
+		for i, obj := range objs {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, obj client.Object) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				results[i] = applyObject(ctx, cli, obj, opts)
+			}(i, obj)
+		}
+		wg.Wait()
+	}
+	applyErr := &ApplyError{}
+	for _, r := range results {
+		if r.Type == ApplyResultFailed || r.Type == ApplyResultSkipped {
+			applyErr.Results = append(applyErr.Results, r)
+		}
+	}
+	if len(applyErr.Results) > 0 {
+		return results, applyErr
+	}
+	return results, nil
+}
+
+func applyObject(ctx context.Context, cli client.Client, obj client.Object, opts ApplyOptions) ApplyResult {
+	obj = obj.DeepCopyObject().(client.Object)
+	failed := func(err error) ApplyResult {
+		return ApplyResult{Object: obj, Type: ApplyResultFailed, Err: WrapError("apply", obj, err)}
+	}
+	gvk, err := apiutil.GVKForObject(obj, cli.Scheme())
+	if err != nil {
+		return failed(err)
+	}
+	if err = defaultObjectNamespace(ctx, cli, obj); err != nil {
+		return failed(err)
+	}
+	// server-side apply requires apiVersion and kind in the request body
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	obj.SetManagedFields(nil)
+	if opts.Origin != nil {
+		SetOrigin(obj, *opts.Origin)
+	}
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(gvk)
+	if err = cli.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil && !kerrors.IsNotFound(err) {
+		return failed(err)
+	}
+	exists := err == nil
+
+	patchOpts := []client.PatchOption{client.FieldOwner(getFieldManager(ctx, opts.FieldManager))}
+	if opts.Force {
+		patchOpts = append(patchOpts, client.ForceOwnership)
+	}
+	dryRun := opts.DryRun || DryRunFrom(ctx)
+	if dryRun {
+		patchOpts = append(patchOpts, client.DryRunAll)
+	}
+	if err = cli.Patch(ctx, obj, client.Apply, patchOpts...); err != nil {
+		return failed(err)
+	}
+	if !exists {
+		return ApplyResult{Object: obj, Type: ApplyResultCreated}
+	}
+	// the resourceVersion is not bumped in dry-run, so the content is compared
+	changed := current.GetResourceVersion() != obj.GetResourceVersion()
+	if dryRun {
+		if changed, err = isContentChanged(current, obj); err != nil {
+			return failed(err)
+		}
+	}
+	if !changed {
+		return ApplyResult{Object: obj, Type: ApplyResultUnchanged}
+	}
+	return ApplyResult{Object: obj, Type: ApplyResultUpdated}
+}
+
+// isContentChanged compares the object before and after applying, ignoring
+// the status and the metadata maintained by the server
+func isContentChanged(current *unstructured.Unstructured, applied client.Object) (bool, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(applied)
+	if err != nil {
+		return false, err
+	}
+	strip := func(obj map[string]interface{}) map[string]interface{} {
+		obj = runtime.DeepCopyJSON(obj)
+		delete(obj, "status")
+		for _, field := range []string{"managedFields", "resourceVersion", "generation", "creationTimestamp"} {
+			unstructured.RemoveNestedField(obj, "metadata", field)
+		}
+		return obj
+	}
+	return !reflect.DeepEqual(strip(current.Object), strip(content)), nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+var errInjected = errors.New("injected")
+
+// applyClient emulates server-side apply on the fake client
+type applyClient struct {
+	client.Client
+}
+
+func (c applyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if obj.GetName() == "bad" {
+		return errInjected
+	}
+	current := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), current); kerrors.IsNotFound(err) {
+		return c.Create(ctx, obj)
+	} else if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(current.GetResourceVersion())
+	patchOpts := &client.PatchOptions{}
+	patchOpts.ApplyOptions(opts)
+	if reflect.DeepEqual(current.GetLabels(), obj.GetLabels()) || len(patchOpts.DryRun) > 0 {
+		return nil
+	}
+	return c.Update(ctx, obj)
+}
+
+func TestApplyAll(t *testing.T) {
+	ctx := context.Background()
+	newCM := func(name string, labels map[string]string) client.Object {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels}}
+	}
+	cli := applyClient{fake.NewClientBuilder().WithObjects(
+		newCM("unchanged", map[string]string{"k": "v"}),
+		newCM("updated", map[string]string{"k": "v"}),
+	).Build()}
+
+	objs := []client.Object{
+		newCM("created", nil),
+		newCM("unchanged", map[string]string{"k": "v"}),
+		newCM("updated", map[string]string{"k": "v2"}),
+		newCM("bad", nil),
+	}
+	results, err := k8s.ApplyAll(ctx, cli, objs, k8s.ApplyOptions{Concurrency: 2})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, errInjected))
+	resourceErr := &k8s.ResourceError{}
+	require.True(t, errors.As(err, &resourceErr))
+	require.Equal(t, "bad", resourceErr.Name)
+	require.True(t, objs[0].GetObjectKind().GroupVersionKind().Empty())
+	require.Empty(t, objs[0].GetResourceVersion())
+	require.NotEmpty(t, results[0].Object.GetResourceVersion())
+	applyErr := &k8s.ApplyError{}
+	require.True(t, errors.As(err, &applyErr))
+	require.Equal(t, 1, len(applyErr.Results))
+	require.Equal(t, "bad", applyErr.Results[0].Object.GetName())
+	require.Contains(t, err.Error(), "injected")
+	var types []k8s.ApplyResultType
+	for _, r := range results {
+		types = append(types, r.Type)
+	}
+	require.Equal(t, []k8s.ApplyResultType{
+		k8s.ApplyResultCreated,
+		k8s.ApplyResultUnchanged,
+		k8s.ApplyResultUpdated,
+		k8s.ApplyResultFailed,
+	}, types)
+
+	results, err = k8s.ApplyAll(ctx, cli, []client.Object{
+		newCM("bad", nil),
+		newCM("another", nil),
+	}, k8s.ApplyOptions{Ordered: true})
+	require.Error(t, err)
+	require.Equal(t, k8s.ApplyResultFailed, results[0].Type)
+	require.Equal(t, k8s.ApplyResultSkipped, results[1].Type)
+	require.True(t, kerrors.IsNotFound(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "another"}, &corev1.ConfigMap{})))
+
+	// dry-run reports the changes without bumping the resourceVersion
+	results, err = k8s.ApplyAll(ctx, cli, []client.Object{
+		newCM("unchanged", map[string]string{"k": "v"}),
+		newCM("updated", map[string]string{"k": "v3"}),
+	}, k8s.ApplyOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, k8s.ApplyResultUnchanged, results[0].Type)
+	require.Equal(t, k8s.ApplyResultUpdated, results[1].Type)
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "updated"}, cm))
+	require.Equal(t, "v2", cm.Labels["k"])
+
+	results, err = k8s.ApplyAll(ctx, cli, nil, k8s.ApplyOptions{})
+	require.NoError(t, err)
+	require.Empty(t, results)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+type key int
+
+const (
+	// namespaceKey is the context key for the default namespace
+	namespaceKey key = iota
+	// fieldManagerKey is the context key for the default field manager
+	fieldManagerKey
+	// dryRunKey is the context key for the dry-run mode
+	dryRunKey
+)
+
+// WithNamespace returns a copy of parent in which the default namespace is
+// set. Helpers in this package use it when the namespace is not specified.
+func WithNamespace(parent context.Context, namespace string) context.Context {
+	return context.WithValue(parent, namespaceKey, namespace)
+}
+
+// NamespaceFrom returns the value of the namespace key on the ctx
+func NamespaceFrom(ctx context.Context) (string, bool) {
+	namespace, ok := ctx.Value(namespaceKey).(string)
+	return namespace, ok
+}
+
+// WithFieldManager returns a copy of parent in which the default field
+// manager is set. Helpers in this package use it when the field manager is not
+// specified.
+func WithFieldManager(parent context.Context, fieldManager string) context.Context {
+	return context.WithValue(parent, fieldManagerKey, fieldManager)
+}
+
+// FieldManagerFrom returns the value of the field manager key on the ctx
+func FieldManagerFrom(ctx context.Context) (string, bool) {
+	fieldManager, ok := ctx.Value(fieldManagerKey).(string)
+	return fieldManager, ok
+}
+
+// WithDryRun returns a copy of parent in which the dry-run mode is set.
+// Writes made by helpers in this package will be sent in server-side dry-run
+// mode if enabled.
+func WithDryRun(parent context.Context, dryRun bool) context.Context {
+	return context.WithValue(parent, dryRunKey, dryRun)
+}
+
+// DryRunFrom returns whether the dry-run mode is enabled on the ctx
+func DryRunFrom(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey).(bool)
+	return dryRun
+}
+
+// getNamespace returns the namespace if not empty, otherwise the default one
+// in the context
+func getNamespace(ctx context.Context, namespace string) string {
+	if namespace == "" {
+		namespace, _ = NamespaceFrom(ctx)
+	}
+	return namespace
+}
+
+// getFieldManager returns the field manager if not empty, otherwise the
+// default one in the context or DefaultFieldManager
+func getFieldManager(ctx context.Context, fieldManager string) string {
+	if fieldManager == "" {
+		fieldManager, _ = FieldManagerFrom(ctx)
+	}
+	if fieldManager == "" {
+		fieldManager = DefaultFieldManager
+	}
+	return fieldManager
+}
+
+// defaultObjectNamespace sets the default namespace in the context to the
+// object if it is namespace-scoped and has no namespace set
+func defaultObjectNamespace(ctx context.Context, cli client.Client, obj client.Object) error {
+	namespace, ok := NamespaceFrom(ctx)
+	if !ok || obj.GetNamespace() != "" {
+		return nil
+	}
+	gvk, err := apiutil.GVKForObject(obj, cli.Scheme())
+	if err != nil {
+		return err
+	}
+	mapping, err := cli.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		obj.SetNamespace(namespace)
+	}
+	return nil
+}
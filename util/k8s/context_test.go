@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+// patchRecorder records the options of the last patch
+type patchRecorder struct {
+	client.Client
+	opts *client.PatchOptions
+}
+
+func (c patchRecorder) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.opts.ApplyOptions(opts)
+	return nil
+}
+
+func TestContext(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	_, ok := k8s.NamespaceFrom(ctx)
+	r.False(ok)
+	_, ok = k8s.FieldManagerFrom(ctx)
+	r.False(ok)
+	r.False(k8s.DryRunFrom(ctx))
+
+	ctx = k8s.WithNamespace(ctx, "example")
+	ctx = k8s.WithFieldManager(ctx, "tester")
+	ctx = k8s.WithDryRun(ctx, true)
+	ns, ok := k8s.NamespaceFrom(ctx)
+	r.True(ok)
+	r.Equal("example", ns)
+	manager, ok := k8s.FieldManagerFrom(ctx)
+	r.True(ok)
+	r.Equal("tester", manager)
+	r.True(k8s.DryRunFrom(ctx))
+
+	opts := &client.PatchOptions{}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	cli := patchRecorder{Client: fake.NewClientBuilder().WithRESTMapper(mapper).Build(), opts: opts}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm"}}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	results, err := k8s.ApplyAll(ctx, cli, []client.Object{cm, namespace}, k8s.ApplyOptions{Ordered: true})
+	r.NoError(err)
+	r.Equal("example", results[0].Object.GetNamespace())
+	r.Equal("", results[1].Object.GetNamespace())
+	r.Equal("", cm.GetNamespace())
+	r.Equal("tester", opts.FieldManager)
+	r.Equal([]string{metav1.DryRunAll}, opts.DryRun)
+
+	*opts = client.PatchOptions{}
+	_, err = k8s.ApplyAll(ctx, cli, []client.Object{cm}, k8s.ApplyOptions{FieldManager: "override"})
+	r.NoError(err)
+	r.Equal("override", opts.FieldManager)
+
+	*opts = client.PatchOptions{}
+	_, err = k8s.ApplyAll(context.Background(), cli, []client.Object{cm}, k8s.ApplyOptions{})
+	r.NoError(err)
+	r.Equal(k8s.DefaultFieldManager, opts.FieldManager)
+	r.Empty(opts.DryRun)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/kubevela/pkg/util/rand"
+)
+
+const (
+	// DefaultDebugContainerTimeout the default timeout for waiting the debug
+	// container to run
+	DefaultDebugContainerTimeout = time.Minute
+	// DefaultDebugContainerPollInterval the default interval for checking the
+	// state of the debug container
+	DefaultDebugContainerPollInterval = time.Second
+)
+
+// DebugContainerOptions the options for attaching debug container
+type DebugContainerOptions struct {
+	// Name the name of the debug container. If empty, a random name with the
+	// prefix debugger- will be used.
+	Name string
+	// TargetContainer the container to share the process namespace with
+	TargetContainer string
+	// Interactive allocate stdin and tty for the debug container
+	Interactive bool
+	Env         []corev1.EnvVar
+	// Timeout the max time waiting for the debug container to run. If not
+	// positive, DefaultDebugContainerTimeout will be used.
+	Timeout time.Duration
+}
+
+// AttachDebugContainer adds an ephemeral container running the image and
+// command into the pod, and waits until it is running. It returns the name of
+// the debug container. Debug containers terminated before being observed
+// running are treated as succeeded, as the command may finish quickly.
+func AttachDebugContainer(ctx context.Context, cli kubernetes.Interface, pod *corev1.Pod, image string, cmd []string, opts DebugContainerOptions) (string, error) {
+	name := opts.Name
+	if name == "" {
+		name = "debugger-" + rand.RandomString(5)
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultDebugContainerTimeout
+	}
+	pods := cli.CoreV1().Pods(pod.Namespace)
+	current, err := pods.Get(ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	container := corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    image,
+			Command:                  cmd,
+			Env:                      opts.Env,
+			ImagePullPolicy:          corev1.PullIfNotPresent,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+			Stdin:                    opts.Interactive,
+			TTY:                      opts.Interactive,
+		},
+		TargetContainerName: opts.TargetContainer,
+	}
+	current.Spec.EphemeralContainers = append(current.Spec.EphemeralContainers, container)
+	if _, err = pods.UpdateEphemeralContainers(ctx, pod.Name, current, metav1.UpdateOptions{}); err != nil {
+		return "", fmt.Errorf("failed to add debug container to pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		if current, err = pods.Get(ctx, pod.Name, metav1.GetOptions{}); err != nil && ctx.Err() == nil {
+			return name, err
+		}
+		if current != nil {
+			for _, status := range current.Status.EphemeralContainerStatuses {
+				if status.Name != name {
+					continue
+				}
+				if status.State.Running != nil || status.State.Terminated != nil {
+					return name, nil
+				}
+				if waiting := status.State.Waiting; waiting != nil && isContainerWaitingFailed(waiting.Reason) {
+					return name, fmt.Errorf("debug container %s failed to start: %s %s", name, waiting.Reason, waiting.Message)
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return name, fmt.Errorf("timeout waiting for debug container %s in pod %s/%s to run", name, pod.Namespace, pod.Name)
+		case <-time.After(DefaultDebugContainerPollInterval):
+		}
+	}
+}
+
+func isContainerWaitingFailed(reason string) bool {
+	switch reason {
+	case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerError", "CreateContainerConfigError":
+		return true
+	}
+	return false
+}
+
+// StreamContainerLogs copies the logs of the container into w until the
+// container terminates if follow is set, or the context is done
+func StreamContainerLogs(ctx context.Context, cli kubernetes.Interface, pod *corev1.Pod, container string, follow bool, w io.Writer) error {
+	stream, err := cli.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container, Follow: follow}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stream.Close() }()
+	_, err = io.Copy(w, stream)
+	return err
+}
+
+// AttachContainer attaches the streams to the running container, such as the
+// interactive debug container. Stdin and tty are used if the streams carry
+// stdin and tty is set.
+func AttachContainer(cfg *rest.Config, cli kubernetes.Interface, pod *corev1.Pod, container string, streams remotecommand.StreamOptions) error {
+	req := cli.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(pod.Namespace).Name(pod.Name).SubResource("attach").
+		VersionedParams(&corev1.PodAttachOptions{
+			Container: container,
+			Stdin:     streams.Stdin != nil,
+			Stdout:    streams.Stdout != nil,
+			Stderr:    streams.Stderr != nil && !streams.Tty,
+			TTY:       streams.Tty,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	return executor.Stream(streams)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+func TestAttachDebugContainer(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app"}}}}
+	cli := fakekube.NewSimpleClientset(pod)
+	pods := cli.CoreV1().Pods("default")
+
+	// mark the debug container running once added
+	go func() {
+		for ctx.Err() == nil {
+			current, err := pods.Get(ctx, "example", metav1.GetOptions{})
+			if err == nil && len(current.Spec.EphemeralContainers) > 0 && len(current.Status.EphemeralContainerStatuses) == 0 {
+				current.Status.EphemeralContainerStatuses = []corev1.ContainerStatus{{
+					Name:  current.Spec.EphemeralContainers[0].Name,
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}}
+				_, _ = pods.UpdateStatus(ctx, current, metav1.UpdateOptions{})
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	name, err := k8s.AttachDebugContainer(ctx, cli, pod, "busybox", []string{"sh"}, k8s.DebugContainerOptions{
+		TargetContainer: "app", Interactive: true})
+	r.NoError(err)
+	r.Contains(name, "debugger-")
+	current, err := pods.Get(ctx, "example", metav1.GetOptions{})
+	r.NoError(err)
+	r.Equal(1, len(current.Spec.EphemeralContainers))
+	container := current.Spec.EphemeralContainers[0]
+	r.Equal("app", container.TargetContainerName)
+	r.True(container.TTY)
+	r.Equal([]string{"sh"}, container.Command)
+
+	_, err = k8s.AttachDebugContainer(ctx, cli, pod, "busybox", nil, k8s.DebugContainerOptions{Name: "timeout", Timeout: 100 * time.Millisecond})
+	r.Error(err)
+	_, err = k8s.AttachDebugContainer(ctx, cli, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "missing"}}, "busybox", nil, k8s.DebugContainerOptions{})
+	r.Error(err)
+
+	buf := &bytes.Buffer{}
+	r.NoError(k8s.StreamContainerLogs(ctx, cli, pod, name, false, buf))
+	r.Equal("fake logs", buf.String())
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ServiceBackend is one backend address of a service, unified from
+// EndpointSlices or legacy Endpoints
+type ServiceBackend struct {
+	IP        string
+	Hostname  string
+	NodeName  string
+	Zone      string
+	TargetRef *corev1.ObjectReference
+	Ports     []ServiceBackendPort
+
+	// Ready indicates the backend is ready to receive traffic
+	Ready bool
+	// Serving is similar to Ready but does not take the terminating state
+	// into consideration. Legacy Endpoints report it the same as Ready.
+	Serving bool
+	// Terminating indicates the backend is terminating. Legacy Endpoints do
+	// not carry this information and always report false.
+	Terminating bool
+}
+
+// ServiceBackendPort is the port exposed by a service backend
+type ServiceBackendPort struct {
+	Name     string
+	Port     int32
+	Protocol corev1.Protocol
+}
+
+// GetServiceBackends returns the backends of the service. It reads the
+// EndpointSlices of the service and falls back to the legacy Endpoints if
+// EndpointSlices are not served by the cluster. If namespace is empty, the
+// namespace set in the context will be used.
+func GetServiceBackends(ctx context.Context, cli client.Client, namespace string, name string) ([]ServiceBackend, error) {
+	namespace = getNamespace(ctx, namespace)
+	slices := &discoveryv1.EndpointSliceList{}
+	err := cli.List(ctx, slices, client.InNamespace(namespace), client.MatchingLabels{discoveryv1.LabelServiceName: name})
+	if err == nil {
+		return BackendsFromEndpointSlices(slices.Items), nil
+	}
+	if !IsCRDNotInstalled(err) && !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+	endpoints := &corev1.Endpoints{}
+	if err = cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, endpoints); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return BackendsFromEndpoints(endpoints), nil
+}
+
+// BackendsFromEndpointSlices converts EndpointSlices into service backends
+func BackendsFromEndpointSlices(slices []discoveryv1.EndpointSlice) []ServiceBackend {
+	var backends []ServiceBackend
+	for _, slice := range slices {
+		ports := make([]ServiceBackendPort, 0, len(slice.Ports))
+		for _, p := range slice.Ports {
+			port := ServiceBackendPort{Protocol: corev1.ProtocolTCP}
+			if p.Name != nil {
+				port.Name = *p.Name
+			}
+			if p.Port != nil {
+				port.Port = *p.Port
+			}
+			if p.Protocol != nil {
+				port.Protocol = *p.Protocol
+			}
+			ports = append(ports, port)
+		}
+		for _, ep := range slice.Endpoints {
+			// nil conditions should be interpreted as ready and serving
+			ready := ep.Conditions.Ready == nil || *ep.Conditions.Ready
+			serving := ready
+			if ep.Conditions.Serving != nil {
+				serving = *ep.Conditions.Serving
+			}
+			terminating := ep.Conditions.Terminating != nil && *ep.Conditions.Terminating
+			for _, addr := range ep.Addresses {
+				backend := ServiceBackend{
+					IP:          addr,
+					TargetRef:   ep.TargetRef,
+					Ports:       ports,
+					Ready:       ready,
+					Serving:     serving,
+					Terminating: terminating,
+				}
+				if ep.Hostname != nil {
+					backend.Hostname = *ep.Hostname
+				}
+				if ep.NodeName != nil {
+					backend.NodeName = *ep.NodeName
+				}
+				if ep.Zone != nil {
+					backend.Zone = *ep.Zone
+				}
+				backends = append(backends, backend)
+			}
+		}
+	}
+	sortServiceBackends(backends)
+	return backends
+}
+
+// BackendsFromEndpoints converts legacy Endpoints into service backends
+func BackendsFromEndpoints(endpoints *corev1.Endpoints) []ServiceBackend {
+	var backends []ServiceBackend
+	for _, subset := range endpoints.Subsets {
+		ports := make([]ServiceBackendPort, 0, len(subset.Ports))
+		for _, p := range subset.Ports {
+			ports = append(ports, ServiceBackendPort{Name: p.Name, Port: p.Port, Protocol: p.Protocol})
+		}
+		add := func(addr corev1.EndpointAddress, ready bool) {
+			backend := ServiceBackend{
+				IP:        addr.IP,
+				Hostname:  addr.Hostname,
+				TargetRef: addr.TargetRef,
+				Ports:     ports,
+				Ready:     ready,
+				Serving:   ready,
+			}
+			if addr.NodeName != nil {
+				backend.NodeName = *addr.NodeName
+			}
+			backends = append(backends, backend)
+		}
+		for _, addr := range subset.Addresses {
+			add(addr, true)
+		}
+		for _, addr := range subset.NotReadyAddresses {
+			add(addr, false)
+		}
+	}
+	sortServiceBackends(backends)
+	return backends
+}
+
+func sortServiceBackends(backends []ServiceBackend) {
+	sort.SliceStable(backends, func(i, j int) bool {
+		return backends[i].IP < backends[j].IP
+	})
+}
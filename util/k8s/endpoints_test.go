@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+func TestGetServiceBackends(t *testing.T) {
+	ctx := context.Background()
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "example-abcde",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "example"},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports:       []discoveryv1.EndpointPort{{Name: pointer.String("http"), Port: pointer.Int32(80)}},
+		Endpoints: []discoveryv1.Endpoint{{
+			Addresses: []string{"10.0.0.2"},
+			Conditions: discoveryv1.EndpointConditions{
+				Ready:       pointer.Bool(false),
+				Serving:     pointer.Bool(true),
+				Terminating: pointer.Bool(true),
+			},
+			NodeName: pointer.String("node"),
+		}, {
+			Addresses: []string{"10.0.0.1"},
+		}},
+	}
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses:         []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+			NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.2", NodeName: pointer.String("node")}},
+			Ports:             []corev1.EndpointPort{{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP}},
+		}},
+	}
+	ports := []k8s.ServiceBackendPort{{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP}}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, discoveryv1.AddToScheme(scheme))
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(slice, endpoints).Build()
+	backends, err := k8s.GetServiceBackends(ctx, cli, "default", "example")
+	require.NoError(t, err)
+	require.Equal(t, []k8s.ServiceBackend{
+		{IP: "10.0.0.1", Ports: ports, Ready: true, Serving: true},
+		{IP: "10.0.0.2", NodeName: "node", Ports: ports, Serving: true, Terminating: true},
+	}, backends)
+
+	// legacy endpoints are used if EndpointSlices are not available
+	scheme = runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	cli = fake.NewClientBuilder().WithScheme(scheme).WithObjects(endpoints).Build()
+	backends, err = k8s.GetServiceBackends(ctx, cli, "default", "example")
+	require.NoError(t, err)
+	require.Equal(t, []k8s.ServiceBackend{
+		{IP: "10.0.0.1", Ports: ports, Ready: true, Serving: true},
+		{IP: "10.0.0.2", NodeName: "node", Ports: ports},
+	}, backends)
+
+	backends, err = k8s.GetServiceBackends(ctx, cli, "default", "not-exist")
+	require.NoError(t, err)
+	require.Empty(t, backends)
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultSecretMask the default value to replace the values from secrets
+const DefaultSecretMask = "******"
+
+// ResolveEnvOptions the options for resolving container env
+type ResolveEnvOptions struct {
+	// MaskSecrets replace the values read from secrets with Mask
+	MaskSecrets bool
+	// Mask the value to replace the secret values. If empty, DefaultSecretMask
+	// will be used.
+	Mask string
+}
+
+var envFieldPathPattern = regexp.MustCompile(`^metadata\.(labels|annotations)\['(.+)'\]$`)
+
+// ResolveContainerEnv returns the env of the container in the pod as the
+// container would see, with envFrom and valueFrom expanded from ConfigMaps,
+// Secrets and the pod fields, and $(VAR) references replaced. The container
+// can be a regular, init or ephemeral container. Resource fields are resolved
+// from the container resources only, without the node allocatable.
+func ResolveContainerEnv(ctx context.Context, cli client.Client, pod *corev1.Pod, container string, opts ResolveEnvOptions) (map[string]string, error) {
+	envFrom, env, resources, found := findContainerEnv(pod, container)
+	if !found {
+		return nil, fmt.Errorf("container %s not found in pod %s/%s", container, pod.Namespace, pod.Name)
+	}
+	mask := opts.Mask
+	if mask == "" {
+		mask = DefaultSecretMask
+	}
+	r := &envResolver{cli: cli, pod: pod, resources: resources, configMaps: map[string]*corev1.ConfigMap{}, secrets: map[string]*corev1.Secret{}}
+	values := map[string]string{}
+	for _, source := range envFrom {
+		var data map[string]string
+		var err error
+		secret := false
+		switch {
+		case source.ConfigMapRef != nil:
+			data, err = r.getConfigMapData(ctx, source.ConfigMapRef.Name, source.ConfigMapRef.Optional)
+		case source.SecretRef != nil:
+			data, err = r.getSecretData(ctx, source.SecretRef.Name, source.SecretRef.Optional)
+			secret = true
+		}
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range data {
+			if secret && opts.MaskSecrets {
+				v = mask
+			}
+			values[source.Prefix+k] = v
+		}
+	}
+	for _, e := range env {
+		if e.ValueFrom == nil {
+			values[e.Name] = expandEnv(e.Value, values)
+			continue
+		}
+		value, exists, secret, err := r.resolve(ctx, e.ValueFrom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve env %s: %w", e.Name, err)
+		}
+		if !exists {
+			continue
+		}
+		if secret && opts.MaskSecrets {
+			value = mask
+		}
+		values[e.Name] = value
+	}
+	return values, nil
+}
+
+func findContainerEnv(pod *corev1.Pod, name string) ([]corev1.EnvFromSource, []corev1.EnvVar, corev1.ResourceRequirements, bool) {
+	for _, containers := range [][]corev1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for _, c := range containers {
+			if c.Name == name {
+				return c.EnvFrom, c.Env, c.Resources, true
+			}
+		}
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		if c.Name == name {
+			return c.EnvFrom, c.Env, c.Resources, true
+		}
+	}
+	return nil, nil, corev1.ResourceRequirements{}, false
+}
+
+// expandEnv replaces $(VAR) in the value with the defined env, and $$ with $.
+// References to undefined env are kept as is.
+func expandEnv(value string, env map[string]string) string {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 >= len(value) {
+			sb.WriteByte(value[i])
+			continue
+		}
+		switch next := value[i+1]; {
+		case next == '$':
+			sb.WriteByte('$')
+			i++
+		case next == '(':
+			end := strings.IndexByte(value[i+2:], ')')
+			if end < 0 {
+				sb.WriteByte('$')
+				continue
+			}
+			ref := value[i : i+2+end+1]
+			if v, found := env[value[i+2:i+2+end]]; found {
+				sb.WriteString(v)
+			} else {
+				sb.WriteString(ref)
+			}
+			i += len(ref) - 1
+		default:
+			sb.WriteByte('$')
+		}
+	}
+	return sb.String()
+}
+
+// envResolver resolves the env sources of the pod and caches the referenced
+// ConfigMaps and Secrets
+type envResolver struct {
+	cli        client.Client
+	pod        *corev1.Pod
+	resources  corev1.ResourceRequirements
+	configMaps map[string]*corev1.ConfigMap
+	secrets    map[string]*corev1.Secret
+}
+
+func (r *envResolver) getConfigMapData(ctx context.Context, name string, optional *bool) (map[string]string, error) {
+	cm, found := r.configMaps[name]
+	if !found {
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: r.pod.Namespace, Name: name}}
+		if err := r.cli.Get(ctx, client.ObjectKeyFromObject(cm), cm); err != nil {
+			if !kerrors.IsNotFound(err) || optional == nil || !*optional {
+				return nil, WrapError("get", cm, err)
+			}
+			cm = nil
+		}
+		r.configMaps[name] = cm
+	}
+	if cm == nil {
+		return nil, nil
+	}
+	data := map[string]string{}
+	for k, v := range cm.Data {
+		data[k] = v
+	}
+	for k, v := range cm.BinaryData {
+		data[k] = string(v)
+	}
+	return data, nil
+}
+
+func (r *envResolver) getSecretData(ctx context.Context, name string, optional *bool) (map[string]string, error) {
+	secret, found := r.secrets[name]
+	if !found {
+		secret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: r.pod.Namespace, Name: name}}
+		if err := r.cli.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+			if !kerrors.IsNotFound(err) || optional == nil || !*optional {
+				return nil, WrapError("get", secret, err)
+			}
+			secret = nil
+		}
+		r.secrets[name] = secret
+	}
+	if secret == nil {
+		return nil, nil
+	}
+	data := map[string]string{}
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	return data, nil
+}
+
+// resolve returns the value of the source, whether the value exists and
+// whether the value is from a secret
+func (r *envResolver) resolve(ctx context.Context, source *corev1.EnvVarSource) (string, bool, bool, error) {
+	switch {
+	case source.ConfigMapKeyRef != nil:
+		ref := source.ConfigMapKeyRef
+		data, err := r.getConfigMapData(ctx, ref.Name, ref.Optional)
+		if err != nil {
+			return "", false, false, err
+		}
+		value, found := data[ref.Key]
+		if !found && data != nil && (ref.Optional == nil || !*ref.Optional) {
+			return "", false, false, fmt.Errorf("key %s not found in configmap %s", ref.Key, ref.Name)
+		}
+		return value, found, false, nil
+	case source.SecretKeyRef != nil:
+		ref := source.SecretKeyRef
+		data, err := r.getSecretData(ctx, ref.Name, ref.Optional)
+		if err != nil {
+			return "", false, true, err
+		}
+		value, found := data[ref.Key]
+		if !found && data != nil && (ref.Optional == nil || !*ref.Optional) {
+			return "", false, true, fmt.Errorf("key %s not found in secret %s", ref.Key, ref.Name)
+		}
+		return value, found, true, nil
+	case source.FieldRef != nil:
+		value, err := r.getPodField(source.FieldRef.FieldPath)
+		return value, err == nil, false, err
+	case source.ResourceFieldRef != nil:
+		value, err := r.getResourceField(source.ResourceFieldRef)
+		return value, err == nil && value != "", false, err
+	}
+	return "", false, false, nil
+}
+
+func (r *envResolver) getPodField(path string) (string, error) {
+	if matches := envFieldPathPattern.FindStringSubmatch(path); matches != nil {
+		if matches[1] == "labels" {
+			return r.pod.Labels[matches[2]], nil
+		}
+		return r.pod.Annotations[matches[2]], nil
+	}
+	switch path {
+	case "metadata.name":
+		return r.pod.Name, nil
+	case "metadata.namespace":
+		return r.pod.Namespace, nil
+	case "metadata.uid":
+		return string(r.pod.UID), nil
+	case "spec.nodeName":
+		return r.pod.Spec.NodeName, nil
+	case "spec.serviceAccountName":
+		return r.pod.Spec.ServiceAccountName, nil
+	case "status.hostIP":
+		return r.pod.Status.HostIP, nil
+	case "status.podIP":
+		return r.pod.Status.PodIP, nil
+	case "status.podIPs":
+		ips := make([]string, 0, len(r.pod.Status.PodIPs))
+		for _, ip := range r.pod.Status.PodIPs {
+			ips = append(ips, ip.IP)
+		}
+		return strings.Join(ips, ","), nil
+	}
+	return "", fmt.Errorf("unsupported field path %s", path)
+}
+
+func (r *envResolver) getResourceField(ref *corev1.ResourceFieldSelector) (string, error) {
+	var quantity resource.Quantity
+	var found bool
+	switch {
+	case strings.HasPrefix(ref.Resource, "limits."):
+		quantity, found = r.resources.Limits[corev1.ResourceName(strings.TrimPrefix(ref.Resource, "limits."))]
+	case strings.HasPrefix(ref.Resource, "requests."):
+		quantity, found = r.resources.Requests[corev1.ResourceName(strings.TrimPrefix(ref.Resource, "requests."))]
+	default:
+		return "", fmt.Errorf("unsupported resource %s", ref.Resource)
+	}
+	if !found {
+		return "", nil
+	}
+	divisor := ref.Divisor
+	if divisor.IsZero() {
+		divisor = resource.MustParse("1")
+	}
+	value := math.Ceil(float64(quantity.MilliValue()) / float64(divisor.MilliValue()))
+	return fmt.Sprintf("%d", int64(value)), nil
+}
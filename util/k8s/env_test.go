@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+func TestResolveContainerEnv(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithObjects(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "config"}, Data: map[string]string{"LEVEL": "debug", "HOST": "db"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "creds"}, Data: map[string][]byte{"password": []byte("secret")}},
+	).Build()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example", Labels: map[string]string{"app": "example"}},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Containers: []corev1.Container{{
+				Name: "app",
+				EnvFrom: []corev1.EnvFromSource{
+					{Prefix: "CFG_", ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "config"}}},
+					{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "missing"}, Optional: pointer.Bool(true)}},
+				},
+				Env: []corev1.EnvVar{
+					{Name: "CFG_LEVEL", Value: "info"},
+					{Name: "URL", Value: "http://$(CFG_HOST):$(PORT)/$$(PATH)"},
+					{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "creds"}, Key: "password"}}},
+					{Name: "OPTIONAL", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "config"}, Key: "missing", Optional: pointer.Bool(true)}}},
+					{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}}},
+					{Name: "APP", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels['app']"}}},
+					{Name: "NODE", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"}}},
+					{Name: "MEMORY", ValueFrom: &corev1.EnvVarSource{ResourceFieldRef: &corev1.ResourceFieldSelector{
+						Resource: "limits.memory", Divisor: resource.MustParse("1Mi")}}},
+					{Name: "CPU", ValueFrom: &corev1.EnvVarSource{ResourceFieldRef: &corev1.ResourceFieldSelector{Resource: "requests.cpu"}}},
+				},
+				Resources: corev1.ResourceRequirements{
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")},
+				},
+			}},
+			InitContainers: []corev1.Container{{
+				Name: "init",
+				Env: []corev1.EnvVar{{Name: "BAD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "creds"}, Key: "missing"}}}},
+			}},
+		},
+	}
+	env, err := k8s.ResolveContainerEnv(ctx, cli, pod, "app", k8s.ResolveEnvOptions{})
+	r.NoError(err)
+	r.Equal(map[string]string{
+		"CFG_LEVEL": "info",
+		"CFG_HOST":  "db",
+		"URL":       "http://db:$(PORT)/$(PATH)",
+		"PASSWORD":  "secret",
+		"POD_NAME":  "example",
+		"APP":       "example",
+		"NODE":      "node-1",
+		"MEMORY":    "1024",
+		"CPU":       "1",
+	}, env)
+
+	env, err = k8s.ResolveContainerEnv(ctx, cli, pod, "app", k8s.ResolveEnvOptions{MaskSecrets: true})
+	r.NoError(err)
+	r.Equal(k8s.DefaultSecretMask, env["PASSWORD"])
+
+	_, err = k8s.ResolveContainerEnv(ctx, cli, pod, "init", k8s.ResolveEnvOptions{})
+	r.Error(err)
+	_, err = k8s.ResolveContainerEnv(ctx, cli, pod, "unknown", k8s.ResolveEnvOptions{})
+	r.Error(err)
+}
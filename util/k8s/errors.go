@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResourceError is the error raised when operating on a resource. It carries
+// the operation and the identity of the resource.
+type ResourceError struct {
+	Operation string
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+	Err       error
+}
+
+// Error .
+func (e *ResourceError) Error() string {
+	name := e.Name
+	if e.Namespace != "" {
+		name = e.Namespace + "/" + name
+	}
+	if e.GVK.Version != "" {
+		name += " (" + e.GVK.GroupVersion().String() + ")"
+	}
+	return fmt.Sprintf("failed to %s %s %s: %s", e.Operation, e.GVK.Kind, name, e.Err.Error())
+}
+
+// Unwrap returns the underlying error
+func (e *ResourceError) Unwrap() error {
+	return e.Err
+}
+
+// WrapError wraps the error returned by the operation on obj into a
+// *ResourceError. It returns nil if err is nil and does not wrap the error
+// twice.
+func WrapError(operation string, obj client.Object, err error) error {
+	if err == nil {
+		return nil
+	}
+	if re := (&ResourceError{}); errors.As(err, &re) {
+		return err
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Kind == "" {
+		gvk.Kind = GetKindForObject(obj, false)
+	}
+	return &ResourceError{
+		Operation: operation,
+		GVK:       gvk,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Err:       err,
+	}
+}
+
+// IsRBACDenied checks if the request is rejected by the RBAC authorizer.
+// Forbidden errors raised by admission webhooks are excluded.
+func IsRBACDenied(err error) bool {
+	return kerrors.IsForbidden(err) && !strings.Contains(err.Error(), "admission webhook")
+}
+
+// IsWebhookTimeout checks if the request failed because an admission or
+// conversion webhook could not respond in time
+func IsWebhookTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "failed calling webhook") && !strings.Contains(msg, "conversion webhook") {
+		return false
+	}
+	return strings.Contains(msg, "context deadline exceeded") ||
+		strings.Contains(msg, "Client.Timeout exceeded") ||
+		strings.Contains(msg, "i/o timeout")
+}
+
+// IsCRDNotInstalled checks if the request failed because the kind is not
+// served by the cluster, which usually means the CRD is not installed
+func IsCRDNotInstalled(err error) bool {
+	if isNoMatchError(err) || isNotRegisteredError(err) {
+		return true
+	}
+	// the discovery of the resource fails, not the object itself is missing
+	var status kerrors.APIStatus
+	if errors.As(err, &status) && kerrors.IsNotFound(err) {
+		details := status.Status().Details
+		return details == nil || details.Name == ""
+	}
+	return false
+}
+
+// isNoMatchError checks if the kind or resource is not found by the REST
+// mapper. Unlike meta.IsNoMatchError, wrapped errors are recognized.
+func isNoMatchError(err error) bool {
+	var noKindMatch *meta.NoKindMatchError
+	var noResourceMatch *meta.NoResourceMatchError
+	return errors.As(err, &noKindMatch) || errors.As(err, &noResourceMatch)
+}
+
+// isNotRegisteredError checks if the kind is not registered in the scheme.
+// The error type is unexported, so the wrapped errors are unwrapped manually.
+func isNotRegisteredError(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if runtime.IsNotRegisteredError(err) {
+			return true
+		}
+	}
+	return false
+}
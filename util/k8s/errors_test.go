@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+func TestWrapError(t *testing.T) {
+	r := require.New(t)
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"}}
+	r.NoError(k8s.WrapError("get", cm, nil))
+
+	notFound := kerrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "example")
+	err := k8s.WrapError("get", cm, notFound)
+	r.Equal(`failed to get ConfigMap default/example: configmaps "example" not found`, err.Error())
+	r.True(kerrors.IsNotFound(err))
+	re := &k8s.ResourceError{}
+	r.True(errors.As(err, &re))
+	r.Equal("get", re.Operation)
+	r.Equal(k8s.WrapError("update", cm, err), err)
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "example"}}
+	ns.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+	r.Equal("failed to delete Namespace example (v1): injected", k8s.WrapError("delete", ns, fmt.Errorf("injected")).Error())
+}
+
+func TestErrorPredicates(t *testing.T) {
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	rbac := kerrors.NewForbidden(gr, "example", fmt.Errorf(`User "alice" cannot get resource "deployments"`))
+	webhookDenied := kerrors.NewForbidden(gr, "example", fmt.Errorf(`admission webhook "validate.example.io" denied the request`))
+	webhookTimeout := kerrors.NewInternalError(fmt.Errorf(`failed calling webhook "validate.example.io": Post "https://svc:443/validate": context deadline exceeded`))
+	noMatch := &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "example.io", Kind: "Foo"}}
+	resourceNotFound := kerrors.NewNotFound(schema.GroupResource{Group: "example.io", Resource: "foos"}, "")
+	objectNotFound := kerrors.NewNotFound(gr, "example")
+	cm := &corev1.ConfigMap{}
+
+	testcases := map[string]struct {
+		err          error
+		rbac         bool
+		timeout      bool
+		notInstalled bool
+	}{
+		"nil":             {err: nil},
+		"rbac":            {err: rbac, rbac: true},
+		"wrapped-rbac":    {err: k8s.WrapError("get", cm, rbac), rbac: true},
+		"webhook-denied":  {err: webhookDenied},
+		"webhook-timeout": {err: k8s.WrapError("create", cm, webhookTimeout), timeout: true},
+		"no-match":        {err: k8s.WrapError("list", cm, noMatch), notInstalled: true},
+		"wrapped-no-resource-match": {err: fmt.Errorf("mapping: %w", &meta.NoResourceMatchError{
+			PartialResource: schema.GroupVersionResource{Group: "example.io", Resource: "foos"}}), notInstalled: true},
+		"not-registered": {err: k8s.WrapError("get", cm, runtime.NewNotRegisteredErrForKind("test",
+			schema.GroupVersionKind{Group: "example.io", Version: "v1", Kind: "Foo"})), notInstalled: true},
+		"resource-notfound": {err: resourceNotFound, notInstalled: true},
+		"object-notfound":   {err: objectNotFound},
+	}
+	for name, tt := range testcases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.rbac, k8s.IsRBACDenied(tt.err))
+			require.Equal(t, tt.timeout, k8s.IsWebhookTimeout(tt.err))
+			require.Equal(t, tt.notInstalled, k8s.IsCRDNotInstalled(tt.err))
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultEvictionTimeout the default timeout for evicting one pod,
+	// including the time waiting for the PodDisruptionBudget to allow it
+	DefaultEvictionTimeout = 5 * time.Minute
+	// DefaultEvictionPollInterval the default interval for checking if the
+	// evicted pod is deleted
+	DefaultEvictionPollInterval = time.Second
+)
+
+// DefaultEvictionBackoff the default backoff for retrying evictions rejected
+// by PodDisruptionBudgets
+var DefaultEvictionBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    math.MaxInt32,
+	Cap:      30 * time.Second,
+}
+
+// EvictOptions the options for evicting pods
+type EvictOptions struct {
+	// GracePeriodSeconds overrides the grace period of the pod if set
+	GracePeriodSeconds *int64
+	// DryRun evict pods in server-side dry-run mode. It is also enabled if
+	// the dry-run mode is set in the context.
+	DryRun bool
+	// Backoff the backoff for retrying evictions rejected by
+	// PodDisruptionBudgets. If zero, DefaultEvictionBackoff will be used.
+	Backoff wait.Backoff
+	// Timeout the max time for evicting one pod. If not positive,
+	// DefaultEvictionTimeout will be used.
+	Timeout time.Duration
+	// WaitForDeletion wait until the evicted pod is deleted
+	WaitForDeletion bool
+}
+
+// EvictPod evicts the pod through the eviction API, which respects the
+// PodDisruptionBudgets. Evictions rejected by the budgets are retried with
+// backoff until the timeout. Pods already deleted are treated as evicted.
+func EvictPod(ctx context.Context, cli kubernetes.Interface, pod *corev1.Pod, opts EvictOptions) error {
+	timeout, backoff := opts.Timeout, opts.Backoff
+	if timeout <= 0 {
+		timeout = DefaultEvictionTimeout
+	}
+	if backoff.Duration <= 0 {
+		backoff = DefaultEvictionBackoff
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
+		DeleteOptions: &metav1.DeleteOptions{
+			GracePeriodSeconds: opts.GracePeriodSeconds,
+			// avoid evicting the new pod with the same name
+			Preconditions: &metav1.Preconditions{UID: &pod.UID},
+		},
+	}
+	if pod.UID == "" {
+		eviction.DeleteOptions.Preconditions = nil
+	}
+	dryRun := opts.DryRun || DryRunFrom(ctx)
+	if dryRun {
+		eviction.DeleteOptions.DryRun = []string{metav1.DryRunAll}
+	}
+	for {
+		err := cli.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction)
+		switch {
+		case err == nil:
+		case kerrors.IsNotFound(err):
+			return nil
+		case kerrors.IsTooManyRequests(err):
+			// rejected by the PodDisruptionBudget, retry later
+			delay := backoff.Step()
+			klog.V(4).Infof("eviction of pod %s/%s is rejected, retry after %s: %s", pod.Namespace, pod.Name, delay, err.Error())
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("timeout evicting pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			case <-time.After(delay):
+			}
+			continue
+		default:
+			return fmt.Errorf("failed to evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		if !opts.WaitForDeletion || dryRun {
+			return nil
+		}
+		return waitForPodDeletion(ctx, cli, pod)
+	}
+}
+
+func waitForPodDeletion(ctx context.Context, cli kubernetes.Interface, pod *corev1.Pod) error {
+	for {
+		current, err := cli.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) || (err == nil && current.UID != pod.UID) {
+			return nil
+		}
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for pod %s/%s to be deleted", pod.Namespace, pod.Name)
+		case <-time.After(DefaultEvictionPollInterval):
+		}
+	}
+}
+
+// EvictPodsOptions the options for evicting pods in batches
+type EvictPodsOptions struct {
+	EvictOptions
+	// BatchSize the number of pods evicted at the same time. If not positive,
+	// pods will be evicted one by one.
+	BatchSize int
+	// Interval the time to wait between batches
+	Interval time.Duration
+}
+
+// EvictPods evicts the pods gradually in batches, such as draining the pods
+// of a workload. Each batch waits for the evicted pods to be deleted before
+// the next batch starts. It stops at the first failed batch and returns the
+// number of pods evicted.
+func EvictPods(ctx context.Context, cli kubernetes.Interface, pods []corev1.Pod, opts EvictPodsOptions) (int, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	evictOpts := opts.EvictOptions
+	evictOpts.WaitForDeletion = true
+	evicted := 0
+	for start := 0; start < len(pods); start += batchSize {
+		if start > 0 && opts.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				return evicted, ctx.Err()
+			case <-time.After(opts.Interval):
+			}
+		}
+		end := start + batchSize
+		if end > len(pods) {
+			end = len(pods)
+		}
+		errs := make([]error, end-start)
+		wg := sync.WaitGroup{}
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i-start] = EvictPod(ctx, cli, &pods[i], evictOpts)
+			}(i)
+		}
+		wg.Wait()
+		var batchErr error
+		for _, err := range errs {
+			if err == nil {
+				evicted++
+			} else if batchErr == nil {
+				batchErr = err
+			}
+		}
+		if batchErr != nil {
+			return evicted, batchErr
+		}
+	}
+	return evicted, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+func TestEvictPods(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID("uid-" + name)}}
+	}
+	cli := fake.NewSimpleClientset(newPod("a"), newPod("protected"), newPod("b"), newPod("broken"))
+	mu := sync.Mutex{}
+	rejections := 2
+	var dryRuns int
+	cli.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(clienttesting.CreateAction).GetObject().(*policyv1.Eviction)
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case len(eviction.DeleteOptions.DryRun) > 0:
+			dryRuns++
+			return true, nil, nil
+		case eviction.Name == "broken":
+			return true, nil, fmt.Errorf("injected")
+		case eviction.Name == "protected" && rejections > 0:
+			rejections--
+			return true, nil, kerrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+		}
+		if string(*eviction.DeleteOptions.Preconditions.UID) != "uid-"+eviction.Name {
+			return true, nil, kerrors.NewConflict(schema.GroupResource{Resource: "pods"}, eviction.Name, nil)
+		}
+		return true, nil, cli.Tracker().Delete(schema.GroupVersionResource{Version: "v1", Resource: "pods"}, eviction.Namespace, eviction.Name)
+	})
+	opts := k8s.EvictOptions{Backoff: wait.Backoff{Duration: 10 * time.Millisecond, Factor: 1, Steps: 100}}
+
+	r.NoError(k8s.EvictPod(k8s.WithDryRun(ctx, true), cli, newPod("a"), opts))
+	r.Equal(1, dryRuns)
+
+	evicted, err := k8s.EvictPods(ctx, cli, []corev1.Pod{*newPod("a"), *newPod("protected"), *newPod("b"), *newPod("broken")}, k8s.EvictPodsOptions{
+		EvictOptions: opts,
+		BatchSize:    2,
+	})
+	r.Error(err)
+	r.Contains(err.Error(), "injected")
+	r.Equal(3, evicted)
+	mu.Lock()
+	r.Equal(0, rejections)
+	mu.Unlock()
+	pods, err := cli.CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+	r.NoError(err)
+	r.Equal(1, len(pods.Items))
+
+	// already deleted
+	r.NoError(k8s.EvictPod(ctx, cli, newPod("a"), opts))
+
+	mu.Lock()
+	rejections = 100
+	mu.Unlock()
+	_, err = cli.CoreV1().Pods("default").Create(ctx, newPod("protected"), metav1.CreateOptions{})
+	r.NoError(err)
+	opts.Timeout = 100 * time.Millisecond
+	err = k8s.EvictPod(ctx, cli, newPod("protected"), opts)
+	r.Error(err)
+	r.Contains(err.Error(), "timeout")
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/util/oci"
+)
+
+// ParseImage parses the image reference in the format of
+// [registry/]repository[:tag][@digest]. Images without registry are
+// normalized to docker.io, and official images to the library repository.
+func ParseImage(image string) (oci.Reference, error) {
+	return oci.ParseReference(image)
+}
+
+// RegistryCredential the credential for the image registry
+type RegistryCredential struct {
+	Username string
+	Password string
+}
+
+// dockerConfig the content of .dockerconfigjson, the content of .dockercfg is
+// the Auths field only
+type dockerConfig struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Auth     string `json:"auth,omitempty"`
+}
+
+// GetRegistryCredential finds the credential for the registry in the image
+// pull secrets. It returns nil if no secret contains the registry. Missing
+// secrets are skipped as the kubelet does.
+func GetRegistryCredential(ctx context.Context, cli client.Client, namespace string, pullSecrets []corev1.LocalObjectReference, registry string) (*RegistryCredential, error) {
+	namespace = getNamespace(ctx, namespace)
+	for _, ref := range pullSecrets {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: ref.Name}}
+		if err := cli.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return nil, WrapError("get", secret, err)
+		}
+		config := dockerConfig{}
+		switch secret.Type {
+		case corev1.SecretTypeDockerConfigJson:
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+				return nil, fmt.Errorf("invalid docker config in secret %s/%s: %w", namespace, ref.Name, err)
+			}
+		case corev1.SecretTypeDockercfg:
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &config.Auths); err != nil {
+				return nil, fmt.Errorf("invalid docker config in secret %s/%s: %w", namespace, ref.Name, err)
+			}
+		default:
+			continue
+		}
+		for server, entry := range config.Auths {
+			if normalizeRegistry(server) != normalizeRegistry(registry) {
+				continue
+			}
+			cred := &RegistryCredential{Username: entry.Username, Password: entry.Password}
+			if entry.Auth != "" {
+				decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+				if err != nil {
+					return nil, fmt.Errorf("invalid auth for %s in secret %s/%s: %w", server, namespace, ref.Name, err)
+				}
+				cred.Username, cred.Password, _ = strings.Cut(string(decoded), ":")
+			}
+			return cred, nil
+		}
+	}
+	return nil, nil
+}
+
+// normalizeRegistry converts the server address in docker config like
+// https://index.docker.io/v1/ into the registry host
+func normalizeRegistry(server string) string {
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	server, _, _ = strings.Cut(server, "/")
+	switch server {
+	case "index.docker.io", "registry-1.docker.io":
+		return oci.DefaultRegistry
+	}
+	return server
+}
+
+// ResolveImageDigest resolves the digest of the image through the registry.
+// The credential is taken from the image pull secrets in the namespace if
+// any. Images already pinned by digest are returned without requests.
+func ResolveImageDigest(ctx context.Context, cli client.Client, namespace string, image string, pullSecrets []corev1.LocalObjectReference, options ...oci.ClientOption) (string, error) {
+	ref, err := ParseImage(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+	cred, err := GetRegistryCredential(ctx, cli, namespace, pullSecrets, ref.Registry)
+	if err != nil {
+		return "", err
+	}
+	if cred != nil {
+		options = append(options, oci.WithBasicAuth{Username: cred.Username, Password: cred.Password})
+	}
+	desc, err := oci.NewClient(options...).Resolve(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	return desc.Digest, nil
+}
+
+// PinImageDigest returns the image reference pinned by the resolved digest,
+// like registry/repository:tag@sha256:...
+func PinImageDigest(ctx context.Context, cli client.Client, namespace string, image string, pullSecrets []corev1.LocalObjectReference, options ...oci.ClientOption) (string, error) {
+	ref, err := ParseImage(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest, err = ResolveImageDigest(ctx, cli, namespace, image, pullSecrets, options...); err != nil {
+		return "", err
+	}
+	return ref.String(), nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/k8s"
+	"github.com/kubevela/pkg/util/oci"
+)
+
+func TestParseImage(t *testing.T) {
+	ref, err := k8s.ParseImage("nginx:1.21")
+	require.NoError(t, err)
+	require.Equal(t, "docker.io/library/nginx:1.21", ref.String())
+	_, err = k8s.ParseImage("Nginx")
+	require.Error(t, err)
+}
+
+func TestResolveImageDigest(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	digest := oci.Digest([]byte("manifest"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, pass, ok := req.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if req.Method != http.MethodHead || req.URL.Path != "/v2/vela/app/manifests/v1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", oci.MediaTypeDockerManifest)
+		w.Header().Set("Docker-Content-Digest", digest)
+	}))
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "http://")
+	cli := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "hub"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{"https://index.docker.io/v1/":{"username":"bob","password":"x"},` +
+				`"http://` + registry + `":{"auth":"` + base64.StdEncoding.EncodeToString([]byte("alice:secret")) + `"}}}`)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "opaque"},
+			Data:       map[string][]byte{"k": []byte("v")},
+		},
+	).Build()
+	secrets := []corev1.LocalObjectReference{{Name: "opaque"}, {Name: "hub"}}
+
+	cred, err := k8s.GetRegistryCredential(ctx, cli, "default", secrets, "docker.io")
+	r.NoError(err)
+	r.Equal(&k8s.RegistryCredential{Username: "bob", Password: "x"}, cred)
+	cred, err = k8s.GetRegistryCredential(ctx, cli, "default", secrets, "ghcr.io")
+	r.NoError(err)
+	r.Nil(cred)
+	// missing secrets are skipped
+	cred, err = k8s.GetRegistryCredential(ctx, cli, "default", []corev1.LocalObjectReference{{Name: "missing"}, {Name: "hub"}}, "docker.io")
+	r.NoError(err)
+	r.Equal(&k8s.RegistryCredential{Username: "bob", Password: "x"}, cred)
+	cred, err = k8s.GetRegistryCredential(ctx, cli, "default", []corev1.LocalObjectReference{{Name: "missing"}}, "docker.io")
+	r.NoError(err)
+	r.Nil(cred)
+
+	pinned, err := k8s.PinImageDigest(ctx, cli, "default", registry+"/vela/app:v1", secrets, oci.WithPlainHTTP(true))
+	r.NoError(err)
+	r.Equal(registry+"/vela/app:v1@"+digest, pinned)
+
+	_, err = k8s.ResolveImageDigest(ctx, cli, "default", registry+"/vela/app:v1", nil, oci.WithPlainHTTP(true))
+	r.Error(err)
+
+	resolved, err := k8s.ResolveImageDigest(ctx, cli, "default", "nginx@"+digest, nil)
+	r.NoError(err)
+	r.Equal(digest, resolved)
+}
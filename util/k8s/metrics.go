@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrMetricsUnavailable the metrics API is not served by the cluster, usually
+// because the metrics-server is not installed or not ready
+var ErrMetricsUnavailable = errors.New("metrics API is unavailable")
+
+// MetricsGroupVersion the group version of the metrics API
+var MetricsGroupVersion = schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"}
+
+// ContainerMetrics the resource usage of a container
+type ContainerMetrics struct {
+	Name  string
+	Usage corev1.ResourceList
+}
+
+// PodMetrics the resource usage of a pod
+type PodMetrics struct {
+	Namespace  string
+	Name       string
+	Timestamp  time.Time
+	Window     time.Duration
+	Containers []ContainerMetrics
+}
+
+// Usage returns the total resource usage of all the containers
+func (m PodMetrics) Usage() corev1.ResourceList {
+	usage := corev1.ResourceList{}
+	for _, c := range m.Containers {
+		for name, q := range c.Usage {
+			total := usage[name]
+			total.Add(q)
+			usage[name] = total
+		}
+	}
+	return usage
+}
+
+// NodeMetrics the resource usage of a node
+type NodeMetrics struct {
+	Name      string
+	Timestamp time.Time
+	Window    time.Duration
+	Usage     corev1.ResourceList
+}
+
+// GetPodMetrics returns the live resource usage of the pods from the metrics
+// API. Pods without metrics, such as the ones just started, are absent from
+// the result. If the metrics API is not available, ErrMetricsUnavailable is
+// returned so that callers can degrade gracefully.
+func GetPodMetrics(ctx context.Context, cli client.Client, pods []client.ObjectKey) (map[client.ObjectKey]PodMetrics, error) {
+	wanted := map[string]map[string]struct{}{}
+	for _, pod := range pods {
+		if wanted[pod.Namespace] == nil {
+			wanted[pod.Namespace] = map[string]struct{}{}
+		}
+		wanted[pod.Namespace][pod.Name] = struct{}{}
+	}
+	namespaces := make([]string, 0, len(wanted))
+	for ns := range wanted {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	result := map[client.ObjectKey]PodMetrics{}
+	for _, ns := range namespaces {
+		items, err := listMetrics(ctx, cli, "PodMetricsList", client.InNamespace(ns))
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if _, found := wanted[ns][item.GetName()]; !found {
+				continue
+			}
+			m := PodMetrics{Namespace: item.GetNamespace(), Name: item.GetName()}
+			if m.Timestamp, m.Window, err = parseMetricsWindow(item); err != nil {
+				return nil, err
+			}
+			containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+			for _, c := range containers {
+				container, _ := c.(map[string]interface{})
+				name, _, _ := unstructured.NestedString(container, "name")
+				usage, err := parseUsage(container)
+				if err != nil {
+					return nil, fmt.Errorf("invalid usage of container %s in pod %s/%s: %w", name, m.Namespace, m.Name, err)
+				}
+				m.Containers = append(m.Containers, ContainerMetrics{Name: name, Usage: usage})
+			}
+			result[client.ObjectKey{Namespace: m.Namespace, Name: m.Name}] = m
+		}
+	}
+	return result, nil
+}
+
+// GetNodeMetrics returns the live resource usage of the nodes from the
+// metrics API. If no names are given, all the nodes are returned. If the
+// metrics API is not available, ErrMetricsUnavailable is returned.
+func GetNodeMetrics(ctx context.Context, cli client.Client, names ...string) (map[string]NodeMetrics, error) {
+	items, err := listMetrics(ctx, cli, "NodeMetricsList")
+	if err != nil {
+		return nil, err
+	}
+	wanted := map[string]struct{}{}
+	for _, name := range names {
+		wanted[name] = struct{}{}
+	}
+	result := map[string]NodeMetrics{}
+	for _, item := range items {
+		if _, found := wanted[item.GetName()]; len(names) > 0 && !found {
+			continue
+		}
+		m := NodeMetrics{Name: item.GetName()}
+		if m.Timestamp, m.Window, err = parseMetricsWindow(item); err != nil {
+			return nil, err
+		}
+		if m.Usage, err = parseUsage(item.Object); err != nil {
+			return nil, fmt.Errorf("invalid usage of node %s: %w", m.Name, err)
+		}
+		result[m.Name] = m
+	}
+	return result, nil
+}
+
+func listMetrics(ctx context.Context, cli client.Client, listKind string, opts ...client.ListOption) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(MetricsGroupVersion.WithKind(listKind))
+	if err := cli.List(ctx, list, opts...); err != nil {
+		if IsCRDNotInstalled(err) || kerrors.IsServiceUnavailable(err) {
+			return nil, fmt.Errorf("%w: %s", ErrMetricsUnavailable, err.Error())
+		}
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func parseMetricsWindow(item unstructured.Unstructured) (timestamp time.Time, window time.Duration, err error) {
+	if s, _, _ := unstructured.NestedString(item.Object, "timestamp"); s != "" {
+		if timestamp, err = time.Parse(time.RFC3339, s); err != nil {
+			return timestamp, window, fmt.Errorf("invalid timestamp of %s: %w", item.GetName(), err)
+		}
+	}
+	if s, _, _ := unstructured.NestedString(item.Object, "window"); s != "" {
+		if window, err = time.ParseDuration(s); err != nil {
+			return timestamp, window, fmt.Errorf("invalid window of %s: %w", item.GetName(), err)
+		}
+	}
+	return timestamp, window, nil
+}
+
+func parseUsage(obj map[string]interface{}) (corev1.ResourceList, error) {
+	raw, _, err := unstructured.NestedStringMap(obj, "usage")
+	if err != nil {
+		return nil, err
+	}
+	usage := corev1.ResourceList{}
+	for name, s := range raw {
+		q, err := resource.ParseQuantity(s)
+		if err != nil {
+			return nil, err
+		}
+		usage[corev1.ResourceName(name)] = q
+	}
+	return usage, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+// noMetricsClient emulates the cluster without metrics-server
+type noMetricsClient struct {
+	client.Client
+}
+
+func (c noMetricsClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return &meta.NoKindMatchError{GroupKind: k8s.MetricsGroupVersion.WithKind("PodMetrics").GroupKind()}
+}
+
+func TestGetMetrics(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	podMetrics := func(namespace, name string) client.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "metrics.k8s.io/v1beta1",
+			"kind":       "PodMetrics",
+			"metadata":   map[string]interface{}{"namespace": namespace, "name": name},
+			"timestamp":  "2022-06-01T00:00:00Z",
+			"window":     "30s",
+			"containers": []interface{}{
+				map[string]interface{}{"name": "main", "usage": map[string]interface{}{"cpu": "100m", "memory": "64Mi"}},
+				map[string]interface{}{"name": "sidecar", "usage": map[string]interface{}{"cpu": "50m", "memory": "16Mi"}},
+			},
+		}}
+	}
+	nodeMetrics := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"kind":       "NodeMetrics",
+		"metadata":   map[string]interface{}{"name": "node-1"},
+		"window":     "20s",
+		"usage":      map[string]interface{}{"cpu": "2", "memory": "4Gi"},
+	}}
+	cli := fake.NewClientBuilder().WithObjects(podMetrics("default", "a"), podMetrics("default", "b"), podMetrics("vela-system", "c"), nodeMetrics).Build()
+
+	pods, err := k8s.GetPodMetrics(ctx, cli, []client.ObjectKey{
+		{Namespace: "default", Name: "a"},
+		{Namespace: "vela-system", Name: "c"},
+		{Namespace: "vela-system", Name: "pending"},
+	})
+	r.NoError(err)
+	r.Equal(2, len(pods))
+	a := pods[client.ObjectKey{Namespace: "default", Name: "a"}]
+	r.Equal(30*time.Second, a.Window)
+	r.Equal(time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC), a.Timestamp.UTC())
+	r.Equal(2, len(a.Containers))
+	usage := a.Usage()
+	r.True(resource.MustParse("150m").Equal(usage[corev1.ResourceCPU]))
+	r.True(resource.MustParse("80Mi").Equal(usage[corev1.ResourceMemory]))
+
+	nodes, err := k8s.GetNodeMetrics(ctx, cli)
+	r.NoError(err)
+	r.Equal(1, len(nodes))
+	r.True(resource.MustParse("4Gi").Equal(nodes["node-1"].Usage[corev1.ResourceMemory]))
+	nodes, err = k8s.GetNodeMetrics(ctx, cli, "node-2")
+	r.NoError(err)
+	r.Empty(nodes)
+
+	_, err = k8s.GetPodMetrics(ctx, noMetricsClient{cli}, []client.ObjectKey{{Namespace: "default", Name: "a"}})
+	r.True(errors.Is(err, k8s.ErrMetricsUnavailable))
+	_, err = k8s.GetNodeMetrics(ctx, noMetricsClient{cli})
+	r.True(errors.Is(err, k8s.ErrMetricsUnavailable))
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultMigrationPageSize the default number of objects listed in each page
+// during the migration
+const DefaultMigrationPageSize = 100
+
+// crdGVK the GroupVersionKind of CustomResourceDefinition
+var crdGVK = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+
+// MigrationProgress reports the progress of the CRD version migration
+type MigrationProgress struct {
+	// Migrated the number of objects rewritten so far
+	Migrated int
+	// Failed the number of objects failed to be rewritten so far
+	Failed int
+	// Continue the token to resume the migration from the next page. It is
+	// empty after the last page.
+	Continue string
+}
+
+// ConvertFunc converts the object listed in the old version into the new
+// version. The apiVersion will be set to the new version after conversion.
+type ConvertFunc func(obj *unstructured.Unstructured) error
+
+// MigrateOptions the options for MigrateCRDVersion
+type MigrateOptions struct {
+	// Convert converts objects on the client side, and the converted objects
+	// are written in the to version. If nil, objects are written back in the
+	// from version as they are read, and the API server converts them into
+	// the storage version.
+	Convert ConvertFunc
+	// PageSize the number of objects listed in each page. If not positive,
+	// DefaultMigrationPageSize will be used.
+	PageSize int64
+	// Continue resumes the migration from the token reported by a previous
+	// migration
+	Continue string
+	// OnProgress is called after each page is migrated. The progress can be
+	// persisted to resume the migration later.
+	OnProgress func(MigrationProgress)
+}
+
+// MigrateCRDVersion rewrites all the stored objects of the CRD, which are
+// listed in the from version, so that they are persisted in the storage
+// version to. Once all objects are migrated, the storedVersions of the CRD
+// will be reset to the to version. The to version must be the storage version
+// of the CRD.
+func MigrateCRDVersion(ctx context.Context, cli client.Client, crdName string, from string, to string, opts MigrateOptions) error {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGVK)
+	crd.SetName(crdName)
+	if err := cli.Get(ctx, client.ObjectKey{Name: crdName}, crd); err != nil {
+		return WrapError("get", crd, err)
+	}
+	if storage := getStorageVersion(crd); storage != to {
+		return fmt.Errorf("cannot migrate %s to version %s, which is not the storage version %s", crdName, to, storage)
+	}
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	listKind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "listKind")
+	if listKind == "" {
+		listKind = kind + "List"
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultMigrationPageSize
+	}
+	progress := MigrationProgress{Continue: opts.Continue}
+	var errs []string
+	for {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.GroupVersionKind{Group: group, Version: from, Kind: listKind})
+		if err := cli.List(ctx, list, client.Limit(pageSize), client.Continue(progress.Continue)); err != nil {
+			return fmt.Errorf("failed to list %s in version %s: %w", kind, from, err)
+		}
+		for i := range list.Items {
+			if err := migrateObject(ctx, cli, &list.Items[i], schema.GroupVersion{Group: group, Version: to}, opts.Convert); err != nil {
+				progress.Failed++
+				errs = append(errs, err.Error())
+			} else {
+				progress.Migrated++
+			}
+		}
+		progress.Continue = list.GetContinue()
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+		if progress.Continue == "" {
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to migrate %d objects: [%s]", len(errs), strings.Join(errs, ", "))
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := cli.Get(ctx, client.ObjectKey{Name: crdName}, crd); err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedStringSlice(crd.Object, []string{to}, "status", "storedVersions"); err != nil {
+			return err
+		}
+		return WrapError("update stored versions of", crd, cli.Status().Update(ctx, crd))
+	})
+}
+
+// getStorageVersion returns the version marked as storage in the CRD spec
+func getStorageVersion(crd *unstructured.Unstructured) string {
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, v := range versions {
+		version, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if storage, _, _ := unstructured.NestedBool(version, "storage"); storage {
+			name, _, _ := unstructured.NestedString(version, "name")
+			return name
+		}
+	}
+	return ""
+}
+
+// migrateObject converts the object and writes it back in the target
+// version. Without the converter, the object is written back in the version
+// it is read in. Conflicts are retried with the latest object.
+func migrateObject(ctx context.Context, cli client.Client, obj *unstructured.Unstructured, gv schema.GroupVersion, convert ConvertFunc) error {
+	key, from := client.ObjectKeyFromObject(obj), obj.GroupVersionKind()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if obj == nil {
+			obj = &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(from)
+			if err := cli.Get(ctx, key, obj); err != nil {
+				return err
+			}
+		}
+		if convert != nil {
+			if err := convert(obj); err != nil {
+				return err
+			}
+			obj.SetAPIVersion(gv.String())
+		}
+		err := cli.Update(ctx, obj)
+		if kerrors.IsConflict(err) {
+			// read the latest object in the old version for the next retry
+			obj = nil
+		}
+		return err
+	})
+	if kerrors.IsNotFound(err) {
+		// the object is deleted during the migration
+		return nil
+	}
+	if err != nil {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(from)
+		u.SetNamespace(key.Namespace)
+		u.SetName(key.Name)
+		return WrapError("migrate", u, err)
+	}
+	return nil
+}
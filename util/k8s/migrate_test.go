@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+// migrationClient records the objects written by the migration. The fake
+// client keys objects by version, so objects written in the new version are
+// stored back in the old version to keep them readable.
+type migrationClient struct {
+	client.Client
+	conflicts int
+	versions  []string
+	written   map[string]*unstructured.Unstructured
+}
+
+func (c *migrationClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	u := obj.(*unstructured.Unstructured)
+	if u.GroupVersionKind().Kind != "Foo" {
+		return c.Client.Update(ctx, obj, opts...)
+	}
+	c.versions = append(c.versions, u.GetAPIVersion())
+	if c.conflicts > 0 {
+		c.conflicts--
+		return kerrors.NewConflict(schema.GroupResource{Group: "example.io", Resource: "foos"}, u.GetName(), nil)
+	}
+	if u.GetName() == "bad" {
+		return fmt.Errorf("injected")
+	}
+	c.written[u.GetName()] = u.DeepCopy()
+	u = u.DeepCopy()
+	u.SetAPIVersion("example.io/v1alpha1")
+	if err := c.Client.Update(ctx, u, opts...); err != nil {
+		return err
+	}
+	obj.SetResourceVersion(u.GetResourceVersion())
+	return nil
+}
+
+func TestMigrateCRDVersion(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "foos.example.io"},
+		"spec": map[string]interface{}{
+			"group": "example.io",
+			"names": map[string]interface{}{"kind": "Foo", "listKind": "FooList"},
+			"versions": []interface{}{
+				map[string]interface{}{"name": "v1alpha1", "served": true, "storage": false},
+				map[string]interface{}{"name": "v1", "served": true, "storage": true},
+			},
+		},
+		"status": map[string]interface{}{"storedVersions": []interface{}{"v1alpha1", "v1"}},
+	}}
+	newFoo := func(name string) client.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.io/v1alpha1",
+			"kind":       "Foo",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec":       map[string]interface{}{"replica": int64(1)},
+		}}
+	}
+	cli := &migrationClient{Client: fake.NewClientBuilder().WithObjects(crd, newFoo("a"), newFoo("b"), newFoo("c")).Build(),
+		conflicts: 1, written: map[string]*unstructured.Unstructured{}}
+
+	var progresses []k8s.MigrationProgress
+	converted := 0
+	err := k8s.MigrateCRDVersion(ctx, cli, "foos.example.io", "v1alpha1", "v1", k8s.MigrateOptions{
+		PageSize: 2,
+		Convert: func(obj *unstructured.Unstructured) error {
+			converted++
+			replica, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replica")
+			return unstructured.SetNestedField(obj.Object, replica, "spec", "replicas")
+		},
+		OnProgress: func(p k8s.MigrationProgress) { progresses = append(progresses, p) },
+	})
+	r.NoError(err)
+	r.Equal(4, converted)
+	r.Equal([]string{"example.io/v1", "example.io/v1", "example.io/v1", "example.io/v1"}, cli.versions)
+	r.NotEmpty(progresses)
+	last := progresses[len(progresses)-1]
+	r.Equal(3, last.Migrated)
+	r.Equal("", last.Continue)
+
+	foo := &unstructured.Unstructured{}
+	foo.SetGroupVersionKind(schema.GroupVersionKind{Group: "example.io", Version: "v1alpha1", Kind: "Foo"})
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "a"}, foo))
+	replicas, _, _ := unstructured.NestedInt64(foo.Object, "spec", "replicas")
+	r.Equal(int64(1), replicas)
+	r.Equal("example.io/v1", cli.written["a"].GetAPIVersion())
+
+	r.NoError(cli.Get(ctx, client.ObjectKey{Name: "foos.example.io"}, crd))
+	versions, _, _ := unstructured.NestedStringSlice(crd.Object, "status", "storedVersions")
+	r.Equal([]string{"v1"}, versions)
+
+	// without the converter, objects are written back in the old version
+	// as they are read
+	r.NoError(cli.Create(ctx, newFoo("d")))
+	cli.versions = nil
+	r.NoError(k8s.MigrateCRDVersion(ctx, cli, "foos.example.io", "v1alpha1", "v1", k8s.MigrateOptions{}))
+	r.Equal([]string{"example.io/v1alpha1", "example.io/v1alpha1", "example.io/v1alpha1", "example.io/v1alpha1"}, cli.versions)
+	r.Equal("example.io/v1alpha1", cli.written["d"].GetAPIVersion())
+	spec, _, _ := unstructured.NestedMap(cli.written["d"].Object, "spec")
+	r.Equal(map[string]interface{}{"replica": int64(1)}, spec)
+
+	r.NoError(cli.Create(ctx, newFoo("bad")))
+	r.NoError(cli.Get(ctx, client.ObjectKey{Name: "foos.example.io"}, crd))
+	r.NoError(unstructured.SetNestedStringSlice(crd.Object, []string{"v1alpha1", "v1"}, "status", "storedVersions"))
+	r.NoError(cli.Status().Update(ctx, crd))
+	err = k8s.MigrateCRDVersion(ctx, cli, "foos.example.io", "v1alpha1", "v1", k8s.MigrateOptions{})
+	r.Error(err)
+	r.Contains(err.Error(), "injected")
+	r.NoError(cli.Get(ctx, client.ObjectKey{Name: "foos.example.io"}, crd))
+	versions, _, _ = unstructured.NestedStringSlice(crd.Object, "status", "storedVersions")
+	r.Equal([]string{"v1alpha1", "v1"}, versions)
+
+	err = k8s.MigrateCRDVersion(ctx, cli, "foos.example.io", "v1", "v1alpha1", k8s.MigrateOptions{})
+	r.Error(err)
+	r.Contains(err.Error(), "not the storage version")
+
+	err = k8s.MigrateCRDVersion(ctx, cli, "bars.example.io", "v1alpha1", "v1", k8s.MigrateOptions{})
+	r.True(kerrors.IsNotFound(err))
+	r.Contains(err.Error(), "CustomResourceDefinition bars.example.io")
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ObserveObject watches the object and emits the current object and the
+// subsequent updates, deduplicated by resource version. A nil object is
+// emitted when the object does not exist or is deleted. The channel is closed
+// when the context is done. If the namespace of the key is empty, the
+// namespace set in the context will be used for namespace-scoped objects.
+func ObserveObject(ctx context.Context, cli client.WithWatch, key client.ObjectKey, gvk schema.GroupVersionKind) (<-chan *unstructured.Unstructured, error) {
+	mapping, err := cli.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		key.Namespace = getNamespace(ctx, key.Namespace)
+	} else {
+		key.Namespace = ""
+	}
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+	listOptions := func(raw metav1.ListOptions) *client.ListOptions {
+		return &client.ListOptions{
+			Namespace:     key.Namespace,
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", key.Name),
+			Raw:           &raw,
+		}
+	}
+	isTarget := func(obj runtime.Object) bool {
+		o, err := meta.Accessor(obj)
+		return err == nil && o.GetName() == key.Name && o.GetNamespace() == key.Namespace
+	}
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(listGVK)
+			if err := cli.List(ctx, list, listOptions(options)); err != nil {
+				return nil, err
+			}
+			items := list.Items[:0]
+			for _, item := range list.Items {
+				if isTarget(&item) {
+					items = append(items, item)
+				}
+			}
+			list.Items = items
+			return list, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(listGVK)
+			w, err := cli.Watch(ctx, list, listOptions(options))
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+				return event, event.Type == watch.Bookmark || event.Type == watch.Error || isTarget(event.Object)
+			}), nil
+		},
+	}
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(gvk)
+	if err = cli.Get(ctx, key, current); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, err
+		}
+		current = nil
+	}
+	ch := make(chan *unstructured.Unstructured)
+	go func() {
+		defer close(ch)
+		watcher := NewResilientWatcher(ctx, lw, 0)
+		defer watcher.Stop()
+		last := current
+		if !sendObject(ctx, ch, current) {
+			return
+		}
+		for event := range watcher.ResultChan() {
+			var obj *unstructured.Unstructured
+			if event.Type != watch.Deleted {
+				var convErr error
+				if obj, convErr = toUnstructured(event.Object, gvk); convErr != nil {
+					continue
+				}
+			}
+			// the object got before watching is delivered again by the
+			// watcher
+			if obj == nil && last == nil || obj != nil && last != nil && obj.GetResourceVersion() == last.GetResourceVersion() {
+				continue
+			}
+			last = obj
+			if !sendObject(ctx, ch, obj) {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// toUnstructured converts the object into unstructured, as some clients
+// deliver typed objects in the watch events even for unstructured lists
+func toUnstructured(obj runtime.Object, gvk schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u, nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+	return u, nil
+}
+
+func sendObject(ctx context.Context, ch chan<- *unstructured.Unstructured, obj *unstructured.Unstructured) bool {
+	select {
+	case ch <- obj:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+// fieldSelectorIgnoringClient drops the field selectors which are not
+// supported by the fake client without indexes
+type fieldSelectorIgnoringClient struct {
+	client.WithWatch
+}
+
+func (c *fieldSelectorIgnoringClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.WithWatch.List(ctx, list, c.strip(opts)...)
+}
+
+func (c *fieldSelectorIgnoringClient) Watch(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	return c.WithWatch.Watch(ctx, list, c.strip(opts)...)
+}
+
+func (c *fieldSelectorIgnoringClient) strip(opts []client.ListOption) []client.ListOption {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	listOpts.FieldSelector = nil
+	return []client.ListOption{listOpts}
+}
+
+func TestObserveObject(t *testing.T) {
+	r := require.New(t)
+	gvk := corev1.SchemeGroupVersion.WithKind("ConfigMap")
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(gvk, meta.RESTScopeNamespace)
+	base := fake.NewClientBuilder().WithRESTMapper(mapper).WithObjects(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other"}},
+	).Build()
+	cli := &fieldSelectorIgnoringClient{WithWatch: base}
+	ctx, cancel := context.WithCancel(k8s.WithNamespace(context.Background(), "default"))
+	defer cancel()
+
+	_, err := k8s.ObserveObject(ctx, cli, client.ObjectKey{Name: "example"}, schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unknown"})
+	r.Error(err)
+
+	ch, err := k8s.ObserveObject(ctx, cli, client.ObjectKey{Name: "example"}, gvk)
+	r.NoError(err)
+	next := func() *unstructured.Unstructured {
+		select {
+		case obj := <-ch:
+			return obj
+		case <-time.After(5 * time.Second):
+			r.FailNow("timeout waiting for object")
+			return nil
+		}
+	}
+	r.Nil(next())
+	// the fake client does not replay events by resource version, wait for
+	// the watch to be established
+	time.Sleep(100 * time.Millisecond)
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"}, Data: map[string]string{"k": "v1"}}
+	r.NoError(base.Create(ctx, cm))
+	obj := next()
+	r.NotNil(obj)
+	r.Equal("v1", obj.Object["data"].(map[string]interface{})["k"])
+
+	r.NoError(base.Update(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other", Labels: map[string]string{"k": "v"}}}))
+	cm.Data["k"] = "v2"
+	r.NoError(base.Update(ctx, cm))
+	obj = next()
+	r.Equal("v2", obj.Object["data"].(map[string]interface{})["k"])
+
+	r.NoError(base.Delete(ctx, cm))
+	r.Nil(next())
+
+	cancel()
+	for range ch {
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	velaruntime "github.com/kubevela/pkg/util/runtime"
+)
+
+const (
+	// LabelOriginController the label for the identity of the controller
+	// managing the object
+	LabelOriginController = "origin.oam.dev/controller"
+	// LabelOriginTemplateHash the label for the hash of the template the
+	// object is rendered from
+	LabelOriginTemplateHash = "origin.oam.dev/template-hash"
+	// AnnotationOriginRuleSetVersion the annotation for the version of the
+	// rule set used to render the object
+	AnnotationOriginRuleSetVersion = "origin.oam.dev/ruleset-version"
+)
+
+// Origin where the applied object comes from
+type Origin struct {
+	// Controller the identity of the managing controller
+	Controller string
+	// TemplateHash the hash of the source template, see HashTemplate
+	TemplateHash string
+	// RuleSetVersion the version of the rule set rendering the object
+	RuleSetVersion string
+}
+
+// HashTemplate returns the short hash of the template in its JSON form, which
+// can be used as the label value
+func HashTemplate(template interface{}) (string, error) {
+	bs, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// SetOrigin stamps the origin on the object. The controller and the template
+// hash are set as labels for querying, and the rule set version is set as
+// annotation. If the controller is empty, the controller in the caller will
+// be used. Empty fields are not set.
+func SetOrigin(obj metav1.Object, origin Origin) {
+	if origin.Controller == "" {
+		origin.Controller = velaruntime.GetControllerInCaller()
+	}
+	labels, annotations := obj.GetLabels(), obj.GetAnnotations()
+	set := func(m map[string]string, key string, value string) map[string]string {
+		if value == "" {
+			return m
+		}
+		if m == nil {
+			m = map[string]string{}
+		}
+		m[key] = value
+		return m
+	}
+	labels = set(labels, LabelOriginController, origin.Controller)
+	labels = set(labels, LabelOriginTemplateHash, origin.TemplateHash)
+	annotations = set(annotations, AnnotationOriginRuleSetVersion, origin.RuleSetVersion)
+	obj.SetLabels(labels)
+	obj.SetAnnotations(annotations)
+}
+
+// GetOrigin returns the origin stamped on the object. It returns false if the
+// object has no origin.
+func GetOrigin(obj metav1.Object) (Origin, bool) {
+	origin := Origin{
+		Controller:     obj.GetLabels()[LabelOriginController],
+		TemplateHash:   obj.GetLabels()[LabelOriginTemplateHash],
+		RuleSetVersion: obj.GetAnnotations()[AnnotationOriginRuleSetVersion],
+	}
+	return origin, origin != Origin{}
+}
+
+// ListByOrigin lists the objects matching the non-empty fields of the origin.
+// The controller and the template hash are matched by the apiserver, while the
+// rule set version is matched after listing.
+func ListByOrigin(ctx context.Context, cli client.Client, list client.ObjectList, origin Origin, opts ...client.ListOption) error {
+	selector := client.MatchingLabels{}
+	if origin.Controller != "" {
+		selector[LabelOriginController] = origin.Controller
+	}
+	if origin.TemplateHash != "" {
+		selector[LabelOriginTemplateHash] = origin.TemplateHash
+	}
+	if err := cli.List(ctx, list, append(opts, selector)...); err != nil {
+		return err
+	}
+	if origin.RuleSetVersion == "" {
+		return nil
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	filtered := items[:0]
+	for _, item := range items {
+		if o, err := meta.Accessor(item); err == nil && o.GetAnnotations()[AnnotationOriginRuleSetVersion] == origin.RuleSetVersion {
+			filtered = append(filtered, item)
+		}
+	}
+	return meta.SetList(list, filtered)
+}
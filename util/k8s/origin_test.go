@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+func TestOrigin(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	hash, err := k8s.HashTemplate(map[string]interface{}{"image": "nginx"})
+	r.NoError(err)
+	r.Equal(16, len(hash))
+	another, err := k8s.HashTemplate(map[string]interface{}{"image": "busybox"})
+	r.NoError(err)
+	r.NotEqual(hash, another)
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "stamped", Labels: map[string]string{"k": "v"}}}
+	_, found := k8s.GetOrigin(cm)
+	r.False(found)
+	k8s.SetOrigin(cm, k8s.Origin{Controller: "app", TemplateHash: hash, RuleSetVersion: "v2"})
+	origin, found := k8s.GetOrigin(cm)
+	r.True(found)
+	r.Equal(k8s.Origin{Controller: "app", TemplateHash: hash, RuleSetVersion: "v2"}, origin)
+	r.Equal("v", cm.Labels["k"])
+
+	cli := applyClient{fake.NewClientBuilder().WithObjects(cm).Build()}
+	_, err = k8s.ApplyAll(ctx, cli, []client.Object{
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "old-rules"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other-template"}},
+	}, k8s.ApplyOptions{Origin: &k8s.Origin{Controller: "app", TemplateHash: hash, RuleSetVersion: "v1"}})
+	r.NoError(err)
+	r.NoError(cli.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unstamped"}}))
+	other := &corev1.ConfigMap{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "other-template"}, other))
+	k8s.SetOrigin(other, k8s.Origin{TemplateHash: another})
+	r.NoError(cli.Update(ctx, other))
+
+	names := func(origin k8s.Origin) []string {
+		cms := &corev1.ConfigMapList{}
+		r.NoError(k8s.ListByOrigin(ctx, cli, cms, origin, client.InNamespace("default")))
+		var names []string
+		for _, item := range cms.Items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+	r.Equal([]string{"old-rules", "other-template", "stamped"}, names(k8s.Origin{Controller: "app"}))
+	r.Equal([]string{"old-rules", "stamped"}, names(k8s.Origin{Controller: "app", TemplateHash: hash}))
+	r.Equal([]string{"stamped"}, names(k8s.Origin{TemplateHash: hash, RuleSetVersion: "v2"}))
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AnnotationReferenceGrant the annotation on the referenced Secret or
+// ConfigMap listing the namespaces allowed to reference it, separated by
+// comma. "*" allows all namespaces.
+const AnnotationReferenceGrant = "reference.oam.dev/allowed-namespaces"
+
+// ReferenceDeniedError the error for references across namespaces which are
+// not granted. Missing objects are denied in the same way, so that the
+// existence of objects in other namespaces is not leaked.
+type ReferenceDeniedError struct {
+	// From the namespace of the referencing workload
+	From      string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// Error .
+func (e *ReferenceDeniedError) Error() string {
+	return fmt.Sprintf("reference to %s %s/%s from namespace %s is not granted", e.Kind, e.Namespace, e.Name, e.From)
+}
+
+// IsReferenceDenied checks if the error is *ReferenceDeniedError
+func IsReferenceDenied(err error) bool {
+	e := &ReferenceDeniedError{}
+	return errors.As(err, &e)
+}
+
+// ReferenceGrantFunc checks if the object can be referenced from the namespace
+type ReferenceGrantFunc func(ctx context.Context, from string, obj client.Object) (bool, error)
+
+// ResolveReferenceOptions the options for resolving references
+type ResolveReferenceOptions struct {
+	// Grant checks if the reference across namespaces is allowed. If nil,
+	// AllowedByAnnotation will be used.
+	Grant ReferenceGrantFunc
+}
+
+// AllowedByAnnotation the default ReferenceGrantFunc which allows the
+// namespaces listed in the AnnotationReferenceGrant annotation. References
+// from the empty namespace, such as cluster-scoped referrers, are denied.
+func AllowedByAnnotation(_ context.Context, from string, obj client.Object) (bool, error) {
+	if from == "" {
+		return false, nil
+	}
+	for _, ns := range strings.Split(obj.GetAnnotations()[AnnotationReferenceGrant], ",") {
+		if ns = strings.TrimSpace(ns); ns != "" && (ns == "*" || ns == from) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ResolveSecretReference gets the Secret referenced by the workload in the
+// namespace from. References in the same namespace are always allowed. If
+// the namespace of the reference is empty, from is used.
+func ResolveSecretReference(ctx context.Context, cli client.Client, from string, namespace string, name string, opts ResolveReferenceOptions) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := resolveReference(ctx, cli, from, namespace, name, secret, opts); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// ResolveConfigMapReference gets the ConfigMap referenced by the workload in
+// the namespace from. References in the same namespace are always allowed. If
+// the namespace of the reference is empty, from is used.
+func ResolveConfigMapReference(ctx context.Context, cli client.Client, from string, namespace string, name string, opts ResolveReferenceOptions) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	if err := resolveReference(ctx, cli, from, namespace, name, cm, opts); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+func resolveReference(ctx context.Context, cli client.Client, from string, namespace string, name string, obj client.Object, opts ResolveReferenceOptions) error {
+	if namespace == "" {
+		namespace = from
+	}
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	err := cli.Get(ctx, key, obj)
+	if namespace == from {
+		return err
+	}
+	denied := &ReferenceDeniedError{From: from, Kind: GetKindForObject(obj, false), Namespace: namespace, Name: name}
+	if kerrors.IsNotFound(err) {
+		return denied
+	}
+	if err != nil {
+		return err
+	}
+	grant := opts.Grant
+	if grant == nil {
+		grant = AllowedByAnnotation
+	}
+	allowed, err := grant(ctx, from, obj)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return denied
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResolveReference(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "private", Namespace: "shared"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "granted", Namespace: "shared",
+			Annotations: map[string]string{AnnotationReferenceGrant: "team-a, team-b"}}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "public", Namespace: "shared",
+			Annotations: map[string]string{AnnotationReferenceGrant: "*"}}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "local", Namespace: "team-a"}},
+	).Build()
+
+	secret, err := ResolveSecretReference(ctx, cli, "team-a", "shared", "granted", ResolveReferenceOptions{})
+	r.NoError(err)
+	r.Equal("granted", secret.Name)
+	_, err = ResolveSecretReference(ctx, cli, "team-c", "shared", "granted", ResolveReferenceOptions{})
+	r.True(IsReferenceDenied(err))
+	r.Contains(err.Error(), "Secret shared/granted")
+	_, err = ResolveSecretReference(ctx, cli, "team-a", "shared", "private", ResolveReferenceOptions{})
+	r.True(IsReferenceDenied(err))
+	_, err = ResolveSecretReference(ctx, cli, "team-a", "shared", "missing", ResolveReferenceOptions{})
+	r.True(IsReferenceDenied(err))
+
+	cm, err := ResolveConfigMapReference(ctx, cli, "team-c", "shared", "public", ResolveReferenceOptions{})
+	r.NoError(err)
+	r.Equal("public", cm.Name)
+	cm, err = ResolveConfigMapReference(ctx, cli, "team-a", "", "local", ResolveReferenceOptions{})
+	r.NoError(err)
+	r.Equal("local", cm.Name)
+	_, err = ResolveConfigMapReference(ctx, cli, "team-a", "", "missing", ResolveReferenceOptions{})
+	r.False(IsReferenceDenied(err))
+	r.Error(err)
+
+	// cluster-scoped referrers are not granted by missing or empty entries
+	_, err = ResolveSecretReference(ctx, cli, "", "shared", "private", ResolveReferenceOptions{})
+	r.True(IsReferenceDenied(err))
+	_, err = ResolveConfigMapReference(ctx, cli, "", "shared", "public", ResolveReferenceOptions{})
+	r.True(IsReferenceDenied(err))
+	for _, annotation := range []string{"", "team-a,,team-b", " , "} {
+		obj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{AnnotationReferenceGrant: annotation}}}
+		allowed, err := AllowedByAnnotation(ctx, "", obj)
+		r.NoError(err)
+		r.False(allowed)
+		allowed, err = AllowedByAnnotation(ctx, "team-c", obj)
+		r.NoError(err)
+		r.False(allowed)
+	}
+
+	grant := func(_ context.Context, from string, obj client.Object) (bool, error) {
+		return from == "admin", nil
+	}
+	_, err = ResolveSecretReference(ctx, cli, "admin", "shared", "private", ResolveReferenceOptions{Grant: grant})
+	r.NoError(err)
+	_, err = ResolveSecretReference(ctx, cli, "team-a", "shared", "granted", ResolveReferenceOptions{Grant: grant})
+	r.True(IsReferenceDenied(err))
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+// DefaultRESTMapperRefreshInterval the default min interval between two
+// refreshes of RefreshingRESTMapper
+const DefaultRESTMapperRefreshInterval = 10 * time.Second
+
+// RefreshingRESTMapper caches the RESTMapper loaded from discovery and reloads
+// it when a kind or resource cannot be matched, so newly installed CRDs are
+// picked up without hitting discovery for every mapping. Reloads are limited
+// to once per refresh interval.
+type RefreshingRESTMapper struct {
+	load            func() (meta.RESTMapper, error)
+	refreshInterval time.Duration
+
+	mu          sync.RWMutex
+	mapper      meta.RESTMapper
+	lastRefresh time.Time
+}
+
+var _ meta.ResettableRESTMapper = &RefreshingRESTMapper{}
+
+// NewRefreshingRESTMapper create a RefreshingRESTMapper which loads the
+// mapper lazily through the load function. If refreshInterval is not
+// positive, DefaultRESTMapperRefreshInterval will be used.
+func NewRefreshingRESTMapper(load func() (meta.RESTMapper, error), refreshInterval time.Duration) *RefreshingRESTMapper {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultRESTMapperRefreshInterval
+	}
+	return &RefreshingRESTMapper{load: load, refreshInterval: refreshInterval}
+}
+
+// NewDiscoveryRESTMapper create a RefreshingRESTMapper loading the mappings
+// from the discovery API of the cluster
+func NewDiscoveryRESTMapper(cfg *rest.Config) (*RefreshingRESTMapper, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewRefreshingRESTMapper(func() (meta.RESTMapper, error) {
+		groupResources, err := restmapper.GetAPIGroupResources(dc)
+		if err != nil {
+			return nil, err
+		}
+		return restmapper.NewDiscoveryRESTMapper(groupResources), nil
+	}, 0), nil
+}
+
+// Reset drops the cached mapper, the next mapping will reload it
+func (m *RefreshingRESTMapper) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mapper = nil
+}
+
+// get returns the cached mapper or loads it. If stale is the mapper failed to
+// match, it will be reloaded unless refreshed recently.
+func (m *RefreshingRESTMapper) get(stale meta.RESTMapper) (meta.RESTMapper, error) {
+	m.mu.RLock()
+	mapper := m.mapper
+	m.mu.RUnlock()
+	if mapper != nil && (stale == nil || mapper != stale) {
+		return mapper, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.mapper != nil && (m.mapper != stale || time.Since(m.lastRefresh) < m.refreshInterval) {
+		return m.mapper, nil
+	}
+	mapper, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	m.mapper, m.lastRefresh = mapper, time.Now()
+	return mapper, nil
+}
+
+// do runs the mapping and retries it after reloading the mapper if nothing
+// matches
+func (m *RefreshingRESTMapper) do(fn func(meta.RESTMapper) error) error {
+	mapper, err := m.get(nil)
+	if err != nil {
+		return err
+	}
+	if err = fn(mapper); !isNoMatchError(err) {
+		return err
+	}
+	reloaded, loadErr := m.get(mapper)
+	if loadErr != nil || reloaded == mapper {
+		return err
+	}
+	return fn(reloaded)
+}
+
+// KindFor .
+func (m *RefreshingRESTMapper) KindFor(resource schema.GroupVersionResource) (gvk schema.GroupVersionKind, err error) {
+	err = m.do(func(mapper meta.RESTMapper) error {
+		gvk, err = mapper.KindFor(resource)
+		return err
+	})
+	return gvk, err
+}
+
+// KindsFor .
+func (m *RefreshingRESTMapper) KindsFor(resource schema.GroupVersionResource) (gvks []schema.GroupVersionKind, err error) {
+	err = m.do(func(mapper meta.RESTMapper) error {
+		gvks, err = mapper.KindsFor(resource)
+		return err
+	})
+	return gvks, err
+}
+
+// ResourceFor .
+func (m *RefreshingRESTMapper) ResourceFor(input schema.GroupVersionResource) (gvr schema.GroupVersionResource, err error) {
+	err = m.do(func(mapper meta.RESTMapper) error {
+		gvr, err = mapper.ResourceFor(input)
+		return err
+	})
+	return gvr, err
+}
+
+// ResourcesFor .
+func (m *RefreshingRESTMapper) ResourcesFor(input schema.GroupVersionResource) (gvrs []schema.GroupVersionResource, err error) {
+	err = m.do(func(mapper meta.RESTMapper) error {
+		gvrs, err = mapper.ResourcesFor(input)
+		return err
+	})
+	return gvrs, err
+}
+
+// RESTMapping .
+func (m *RefreshingRESTMapper) RESTMapping(gk schema.GroupKind, versions ...string) (mapping *meta.RESTMapping, err error) {
+	err = m.do(func(mapper meta.RESTMapper) error {
+		mapping, err = mapper.RESTMapping(gk, versions...)
+		return err
+	})
+	return mapping, err
+}
+
+// RESTMappings .
+func (m *RefreshingRESTMapper) RESTMappings(gk schema.GroupKind, versions ...string) (mappings []*meta.RESTMapping, err error) {
+	err = m.do(func(mapper meta.RESTMapper) error {
+		mappings, err = mapper.RESTMappings(gk, versions...)
+		return err
+	})
+	return mappings, err
+}
+
+// ResourceSingularizer .
+func (m *RefreshingRESTMapper) ResourceSingularizer(resource string) (singular string, err error) {
+	err = m.do(func(mapper meta.RESTMapper) error {
+		singular, err = mapper.ResourceSingularizer(resource)
+		return err
+	})
+	return singular, err
+}
+
+// GetGVKFromResource returns the preferred kind of the resource. If the
+// version is empty, the preferred version will be used.
+func GetGVKFromResource(mapper meta.RESTMapper, resource schema.GroupVersionResource) (schema.GroupVersionKind, error) {
+	return mapper.KindFor(resource)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+func TestRefreshingRESTMapper(t *testing.T) {
+	r := require.New(t)
+	deployGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	fooGVK := schema.GroupVersionKind{Group: "example.io", Version: "v1", Kind: "Foo"}
+	installed := []schema.GroupVersionKind{deployGVK}
+	loads := 0
+	mapper := k8s.NewRefreshingRESTMapper(func() (meta.RESTMapper, error) {
+		loads++
+		m := meta.NewDefaultRESTMapper(nil)
+		for _, gvk := range installed {
+			m.Add(gvk, meta.RESTScopeNamespace)
+		}
+		return m, nil
+	}, 100*time.Millisecond)
+	r.Equal(0, loads)
+
+	gvk, err := k8s.GetGVKFromResource(mapper, schema.GroupVersionResource{Group: "apps", Resource: "deployments"})
+	r.NoError(err)
+	r.Equal(deployGVK, gvk)
+	_, err = mapper.RESTMapping(deployGVK.GroupKind(), "v1")
+	r.NoError(err)
+	r.Equal(1, loads)
+
+	// the CRD is installed after the mapper is loaded
+	time.Sleep(200 * time.Millisecond)
+	installed = append(installed, fooGVK)
+	mapping, err := mapper.RESTMapping(fooGVK.GroupKind(), "v1")
+	r.NoError(err)
+	r.Equal("foos", mapping.Resource.Resource)
+	r.Equal(2, loads)
+
+	// reloads are rate limited
+	_, err = mapper.KindFor(schema.GroupVersionResource{Group: "example.io", Resource: "bars"})
+	r.True(meta.IsNoMatchError(err))
+	r.Equal(2, loads)
+
+	mapper.Reset()
+	singular, err := mapper.ResourceSingularizer("foos")
+	r.NoError(err)
+	r.Equal("foo", singular)
+	r.Equal(3, loads)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ListSelector selects the resources to list. It is decoded from the selector
+// values in templates, such as CUE or JSON, and converted into the options
+// for client.List.
+type ListSelector struct {
+	Namespace        string                            `json:"namespace,omitempty"`
+	Labels           map[string]string                 `json:"labels,omitempty"`
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+	Fields           map[string]string                 `json:"fields,omitempty"`
+	Limit            int64                             `json:"limit,omitempty"`
+}
+
+// ParseListSelector decodes the selector value. Unknown keys are rejected, so
+// that typos in templates are not ignored silently.
+func ParseListSelector(value map[string]interface{}) (*ListSelector, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	selector := &ListSelector{}
+	if err = decoder.Decode(selector); err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+	return selector, nil
+}
+
+// ToListOptions validates the selector and converts it into the list options
+func (s *ListSelector) ToListOptions() (*client.ListOptions, error) {
+	var errs []string
+	opts := &client.ListOptions{Namespace: s.Namespace, Limit: s.Limit}
+	if s.Namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(s.Namespace) {
+			errs = append(errs, fmt.Sprintf("namespace: %s", msg))
+		}
+	}
+	if len(s.Labels) > 0 || len(s.MatchExpressions) > 0 {
+		labelSelector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchLabels: s.Labels, MatchExpressions: s.MatchExpressions})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("labels: %s", err.Error()))
+		} else {
+			opts.LabelSelector = labelSelector
+		}
+	}
+	if len(s.Fields) > 0 {
+		for key := range s.Fields {
+			if key == "" {
+				errs = append(errs, "fields: empty field path")
+			}
+		}
+		opts.FieldSelector = fields.SelectorFromSet(s.Fields)
+	}
+	if s.Limit < 0 {
+		errs = append(errs, fmt.Sprintf("limit: must be non-negative, got %d", s.Limit))
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid selector: [%s]", strings.Join(errs, ", "))
+	}
+	return opts, nil
+}
+
+// ListOptionsFromSelector decodes the selector value and converts it into the
+// list options
+func ListOptionsFromSelector(value map[string]interface{}) (*client.ListOptions, error) {
+	selector, err := ParseListSelector(value)
+	if err != nil {
+		return nil, err
+	}
+	return selector.ToListOptions()
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+func TestListOptionsFromSelector(t *testing.T) {
+	testcases := map[string]struct {
+		value  map[string]interface{}
+		labels string
+		fields string
+		err    string
+	}{
+		"full": {
+			value: map[string]interface{}{
+				"namespace": "default",
+				"labels":    map[string]interface{}{"app": "example"},
+				"matchExpressions": []interface{}{
+					map[string]interface{}{"key": "tier", "operator": "In", "values": []interface{}{"web", "api"}},
+				},
+				"fields": map[string]interface{}{"metadata.name": "example"},
+				"limit":  10,
+			},
+			labels: "app=example,tier in (api,web)",
+			fields: "metadata.name=example",
+		},
+		"empty": {value: map[string]interface{}{}},
+		"unknown-key": {
+			value: map[string]interface{}{"label": map[string]interface{}{"app": "example"}},
+			err:   `unknown field "label"`,
+		},
+		"wrong-type": {
+			value: map[string]interface{}{"limit": "10"},
+			err:   "invalid selector",
+		},
+		"invalid-values": {
+			value: map[string]interface{}{
+				"namespace": "Default",
+				"labels":    map[string]interface{}{"app": "bad value"},
+				"limit":     -1,
+			},
+			err: "namespace",
+		},
+	}
+	for name, tt := range testcases {
+		t.Run(name, func(t *testing.T) {
+			opts, err := k8s.ListOptionsFromSelector(tt.value)
+			if tt.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.labels != "" {
+				require.Equal(t, tt.labels, opts.LabelSelector.String())
+			} else {
+				require.Nil(t, opts.LabelSelector)
+			}
+			if tt.fields != "" {
+				require.Equal(t, tt.fields, opts.FieldSelector.String())
+			}
+		})
+	}
+	_, err := k8s.ListOptionsFromSelector(map[string]interface{}{"labels": map[string]interface{}{"app": "bad value"}, "limit": -1})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "labels:")
+	require.Contains(t, err.Error(), "limit:")
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UpdateStatus reads the latest obj, applies the mutation and patches the
+// status subresource with the difference. The patch carries the
+// resourceVersion, so if the object is changed concurrently, the conflict will
+// be retried with the latest object. Since only the status subresource is
+// patched, concurrent writes to the spec will not be overridden.
+// The namespace, field manager and dry-run mode set in the context are
+// respected.
+func UpdateStatus[T client.Object](ctx context.Context, cli client.Client, obj T, mutate func(obj T)) error {
+	if err := defaultObjectNamespace(ctx, cli, obj); err != nil {
+		return WrapError("update status of", obj, err)
+	}
+	patchOpts := []client.PatchOption{client.FieldOwner(getFieldManager(ctx, ""))}
+	if DryRunFrom(ctx) {
+		patchOpts = append(patchOpts, client.DryRunAll)
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := cli.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return err
+		}
+		original := obj.DeepCopyObject().(T)
+		mutate(obj)
+		return cli.Status().Patch(ctx, obj, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{}), patchOpts...)
+	})
+	return WrapError("update status of", obj, err)
+}
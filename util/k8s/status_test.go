@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+// conflictClient updates the object before the first status patch to emulate
+// concurrent writers
+type conflictClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *conflictClient) Status() client.StatusWriter {
+	if c.conflicts > 0 {
+		c.conflicts--
+		return conflictStatusWriter{}
+	}
+	return c.Client.Status()
+}
+
+type conflictStatusWriter struct {
+	client.StatusWriter
+}
+
+func (w conflictStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return kerrors.NewConflict(schema.GroupResource{Resource: "pods"}, obj.GetName(), nil)
+}
+
+func TestUpdateStatus(t *testing.T) {
+	ctx := context.Background()
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"}}
+	cli := &conflictClient{Client: fake.NewClientBuilder().WithObjects(pod).Build(), conflicts: 2}
+	mutations := 0
+	require.NoError(t, k8s.UpdateStatus(ctx, cli, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"}}, func(pod *corev1.Pod) {
+		mutations++
+		pod.Status.Phase = corev1.PodRunning
+	}))
+	require.Equal(t, 3, mutations)
+	require.NoError(t, cli.Get(ctx, client.ObjectKeyFromObject(pod), pod))
+	require.Equal(t, corev1.PodRunning, pod.Status.Phase)
+
+	err := k8s.UpdateStatus(ctx, cli, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "not-exist"}}, func(pod *corev1.Pod) {})
+	require.True(t, kerrors.IsNotFound(err))
+}
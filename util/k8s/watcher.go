@@ -0,0 +1,248 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// DefaultWatchRetryInterval the default interval for ResilientWatcher to
+// re-establish the watch after failures
+const DefaultWatchRetryInterval = time.Second
+
+// ResilientWatcher keeps watching resources across watch failures. It resumes
+// the watch from the latest resource version (including bookmarks) and falls
+// back to relist if the resource version is too old. The delivered events are
+// deduplicated by resource version, and objects removed during the relist are
+// delivered as Deleted events.
+type ResilientWatcher struct {
+	lw            cache.ListerWatcher
+	retryInterval time.Duration
+
+	resourceVersion string
+	objects         map[string]runtime.Object
+	resultCh        chan watch.Event
+	cancel          context.CancelFunc
+	once            sync.Once
+}
+
+var _ watch.Interface = &ResilientWatcher{}
+
+// NewResilientWatcher create a ResilientWatcher and starts watching. All the
+// existing objects will be delivered as Added events first. If retryInterval
+// is not positive, DefaultWatchRetryInterval will be used.
+func NewResilientWatcher(ctx context.Context, lw cache.ListerWatcher, retryInterval time.Duration) *ResilientWatcher {
+	if retryInterval <= 0 {
+		retryInterval = DefaultWatchRetryInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w := &ResilientWatcher{
+		lw:            lw,
+		retryInterval: retryInterval,
+		objects:       map[string]runtime.Object{},
+		resultCh:      make(chan watch.Event),
+		cancel:        cancel,
+	}
+	go w.run(ctx)
+	return w
+}
+
+// ResultChan returns the channel of the deduplicated events. The channel is
+// closed after the watcher is stopped.
+func (w *ResilientWatcher) ResultChan() <-chan watch.Event {
+	return w.resultCh
+}
+
+// Stop stops the watcher
+func (w *ResilientWatcher) Stop() {
+	w.once.Do(w.cancel)
+}
+
+func (w *ResilientWatcher) run(ctx context.Context) {
+	defer close(w.resultCh)
+	relist := true
+	for ctx.Err() == nil {
+		if relist {
+			if err := w.relist(ctx); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				klog.Warningf("failed to relist resources: %s", err.Error())
+				w.wait(ctx)
+				continue
+			}
+		}
+		var err error
+		relist, err = w.watch(ctx)
+		if err != nil {
+			klog.Warningf("watch interrupted: %s", err.Error())
+			w.wait(ctx)
+		}
+	}
+}
+
+func (w *ResilientWatcher) wait(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(w.retryInterval):
+	}
+}
+
+// send delivers the event unless the watcher is stopped
+func (w *ResilientWatcher) send(ctx context.Context, event watch.Event) bool {
+	select {
+	case w.resultCh <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// relist lists all the objects, delivers the changes compared to the known
+// objects and resets the resource version to watch from
+func (w *ResilientWatcher) relist(ctx context.Context) error {
+	list, err := w.lw.List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	listAccessor, err := meta.ListAccessor(list)
+	if err != nil {
+		return err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	seen := map[string]struct{}{}
+	for _, item := range items {
+		key, rv, err := getObjectKeyAndVersion(item)
+		if err != nil {
+			return err
+		}
+		seen[key] = struct{}{}
+		if !w.observe(ctx, key, rv, watch.Event{Type: watch.Added, Object: item}) {
+			return ctx.Err()
+		}
+	}
+	var removed []string
+	for key := range w.objects {
+		if _, found := seen[key]; !found {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	for _, key := range removed {
+		obj := w.objects[key]
+		delete(w.objects, key)
+		if !w.send(ctx, watch.Event{Type: watch.Deleted, Object: obj}) {
+			return ctx.Err()
+		}
+	}
+	w.resourceVersion = listAccessor.GetResourceVersion()
+	return nil
+}
+
+// observe delivers the Added or Modified event if the object is unknown or
+// its resource version changed. It returns false if the watcher is stopped.
+func (w *ResilientWatcher) observe(ctx context.Context, key string, rv string, event watch.Event) bool {
+	known, found := w.objects[key]
+	if found {
+		if _, knownRV, _ := getObjectKeyAndVersion(known); knownRV == rv {
+			return true
+		}
+		event.Type = watch.Modified
+	} else {
+		event.Type = watch.Added
+	}
+	w.objects[key] = event.Object
+	return w.send(ctx, event)
+}
+
+// watch delivers events until the watch ends. It returns true if relist is
+// required.
+func (w *ResilientWatcher) watch(ctx context.Context) (bool, error) {
+	watcher, err := w.lw.Watch(metav1.ListOptions{ResourceVersion: w.resourceVersion, AllowWatchBookmarks: true})
+	if err != nil {
+		if isResourceVersionTooOld(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	defer watcher.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return false, nil
+			}
+			if event.Type == watch.Error {
+				if err = kerrors.FromObject(event.Object); isResourceVersionTooOld(err) {
+					return true, nil
+				}
+				return false, err
+			}
+			key, rv, err := getObjectKeyAndVersion(event.Object)
+			if err != nil {
+				return false, err
+			}
+			switch event.Type {
+			case watch.Bookmark:
+			case watch.Deleted:
+				if _, found := w.objects[key]; found {
+					delete(w.objects, key)
+					if !w.send(ctx, event) {
+						return false, nil
+					}
+				}
+			default:
+				if !w.observe(ctx, key, rv, event) {
+					return false, nil
+				}
+			}
+			w.resourceVersion = rv
+		}
+	}
+}
+
+func isResourceVersionTooOld(err error) bool {
+	return kerrors.IsResourceExpired(err) || kerrors.IsGone(err)
+}
+
+func getObjectKeyAndVersion(obj runtime.Object) (string, string, error) {
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return "", "", err
+	}
+	key := o.GetName()
+	if o.GetNamespace() != "" {
+		key = o.GetNamespace() + "/" + key
+	}
+	return key, o.GetResourceVersion(), nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/kubevela/pkg/util/k8s"
+)
+
+// fakeListerWatcher serves the configured lists and watchers in order
+type fakeListerWatcher struct {
+	mu       sync.Mutex
+	lists    []*corev1.ConfigMapList
+	watchers []*watch.FakeWatcher
+	watchRVs []string
+}
+
+func (lw *fakeListerWatcher) List(options metav1.ListOptions) (runtime.Object, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	list := lw.lists[0]
+	lw.lists = lw.lists[1:]
+	return list, nil
+}
+
+func (lw *fakeListerWatcher) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	lw.watchRVs = append(lw.watchRVs, options.ResourceVersion)
+	if len(lw.watchers) == 0 {
+		return nil, kerrors.NewServiceUnavailable("no more watchers")
+	}
+	w := lw.watchers[0]
+	lw.watchers = lw.watchers[1:]
+	return w, nil
+}
+
+func newConfigMap(name string, rv string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, ResourceVersion: rv}}
+}
+
+func TestResilientWatcher(t *testing.T) {
+	list := func(rv string, items ...*corev1.ConfigMap) *corev1.ConfigMapList {
+		l := &corev1.ConfigMapList{ListMeta: metav1.ListMeta{ResourceVersion: rv}}
+		for _, item := range items {
+			l.Items = append(l.Items, *item)
+		}
+		return l
+	}
+	w1, w2, w3 := watch.NewFakeWithChanSize(10, false), watch.NewFakeWithChanSize(10, false), watch.NewFakeWithChanSize(10, false)
+	lw := &fakeListerWatcher{
+		lists: []*corev1.ConfigMapList{
+			list("10", newConfigMap("a", "1"), newConfigMap("b", "2")),
+			list("20", newConfigMap("a", "11"), newConfigMap("c", "12")),
+		},
+		watchers: []*watch.FakeWatcher{w1, w2, w3},
+	}
+	// duplicated and bookmark events are not delivered, the watch is resumed
+	// from the latest resource version after closed
+	w1.Modify(newConfigMap("b", "2"))
+	w1.Add(newConfigMap("d", "3"))
+	w1.Action(watch.Bookmark, newConfigMap("", "5"))
+	w1.Stop()
+	// relist after the resource version expired
+	w2.Error(&kerrors.NewResourceExpired("too old").ErrStatus)
+	w3.Delete(newConfigMap("c", "21"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher := k8s.NewResilientWatcher(ctx, lw, time.Millisecond)
+	type record struct {
+		Type watch.EventType
+		Name string
+		RV   string
+	}
+	var records []record
+	for len(records) < 8 {
+		event := <-watcher.ResultChan()
+		cm := event.Object.(*corev1.ConfigMap)
+		records = append(records, record{Type: event.Type, Name: cm.Name, RV: cm.ResourceVersion})
+	}
+	require.Equal(t, []record{
+		{watch.Added, "a", "1"},
+		{watch.Added, "b", "2"},
+		{watch.Added, "d", "3"},
+		{watch.Modified, "a", "11"},
+		{watch.Added, "c", "12"},
+		{watch.Deleted, "b", "2"},
+		{watch.Deleted, "d", "3"},
+		{watch.Deleted, "c", "21"},
+	}, records)
+	watcher.Stop()
+	for range watcher.ResultChan() {
+	}
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	require.Equal(t, []string{"10", "5", "20"}, lw.watchRVs[:3])
+}
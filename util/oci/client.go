@@ -0,0 +1,448 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Client pushes artifacts to and pulls artifacts from OCI registries through
+// the distribution API
+type Client struct {
+	httpClient  *http.Client
+	username    string
+	password    string
+	plainHTTP   bool
+	maxBlobSize int64
+
+	mu    sync.Mutex
+	auths map[string]string
+}
+
+// NewClient create a client for OCI registries
+func NewClient(options ...ClientOption) *Client {
+	c := &Client{
+		httpClient:  http.DefaultClient,
+		maxBlobSize: DefaultMaxBlobSize,
+		auths:       map[string]string{},
+	}
+	for _, op := range options {
+		op.ApplyToClient(c)
+	}
+	return c
+}
+
+// Push uploads the blobs of the artifact and tags the manifest with the tag
+// of the reference. The descriptor of the pushed manifest is returned.
+func (c *Client) Push(ctx context.Context, ref Reference, artifact Artifact) (Descriptor, error) {
+	config := artifact.Config
+	if len(config.Data) == 0 {
+		config = Blob{MediaType: MediaTypeEmptyJSON, Data: []byte("{}")}
+	}
+	manifest := Manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeImageManifest,
+		ArtifactType:  artifact.ArtifactType,
+		Config:        config.Descriptor(),
+		Layers:        []Descriptor{},
+		Annotations:   artifact.Annotations,
+	}
+	for _, blob := range append([]Blob{config}, artifact.Layers...) {
+		if blob.MediaType == "" {
+			return Descriptor{}, fmt.Errorf("media type of blob %s is required", Digest(blob.Data))
+		}
+		if err := c.pushBlob(ctx, ref, blob.Data); err != nil {
+			return Descriptor{}, err
+		}
+	}
+	for _, layer := range artifact.Layers {
+		manifest.Layers = append(manifest.Layers, layer.Descriptor())
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	desc := Descriptor{MediaType: MediaTypeImageManifest, Digest: Digest(data), Size: int64(len(data))}
+	if ref.Digest != "" && ref.Digest != desc.Digest {
+		return Descriptor{}, fmt.Errorf("digest mismatch, expected %s, got %s", ref.Digest, desc.Digest)
+	}
+	identifier := ref.Tag
+	if identifier == "" {
+		identifier = desc.Digest
+	}
+	header := http.Header{"Content-Type": []string{MediaTypeImageManifest}}
+	resp, err := c.do(ctx, ref, true, http.MethodPut, c.repositoryURL(ref)+"/manifests/"+identifier, header, data)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	defer closeResponse(resp)
+	if err = checkResponse(resp, http.StatusCreated, http.StatusOK); err != nil {
+		return Descriptor{}, fmt.Errorf("failed to push manifest %s: %w", ref, err)
+	}
+	return desc, nil
+}
+
+// pushBlob uploads the blob in a single request if it does not exist
+func (c *Client) pushBlob(ctx context.Context, ref Reference, data []byte) error {
+	digest := Digest(data)
+	resp, err := c.do(ctx, ref, true, http.MethodHead, c.repositoryURL(ref)+"/blobs/"+digest, nil, nil)
+	if err != nil {
+		return err
+	}
+	closeResponse(resp)
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	uploadURL := c.repositoryURL(ref) + "/blobs/uploads/"
+	if resp, err = c.do(ctx, ref, true, http.MethodPost, uploadURL, nil, nil); err != nil {
+		return err
+	}
+	err = checkResponse(resp, http.StatusAccepted)
+	closeResponse(resp)
+	if err != nil {
+		return fmt.Errorf("failed to start uploading blob %s: %w", digest, err)
+	}
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("invalid upload location: %w", err)
+	}
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+	header := http.Header{"Content-Type": []string{"application/octet-stream"}}
+	if resp, err = c.do(ctx, ref, true, http.MethodPut, location.String(), header, data); err != nil {
+		return err
+	}
+	err = checkResponse(resp, http.StatusCreated)
+	closeResponse(resp)
+	if err != nil {
+		return fmt.Errorf("failed to upload blob %s: %w", digest, err)
+	}
+	return nil
+}
+
+// Resolve returns the descriptor of the manifest addressed by the reference
+// without downloading it. Image indexes and Docker manifests are accepted as
+// well, so it can be used to resolve the digest of container images.
+func (c *Client) Resolve(ctx context.Context, ref Reference) (Descriptor, error) {
+	header := http.Header{"Accept": resolvableMediaTypes}
+	resp, err := c.do(ctx, ref, false, http.MethodHead, c.repositoryURL(ref)+"/manifests/"+ref.Identifier(), header, nil)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	err = checkResponse(resp, http.StatusOK)
+	closeResponse(resp)
+	if err != nil {
+		return Descriptor{}, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	desc := Descriptor{
+		MediaType: resp.Header.Get("Content-Type"),
+		Digest:    resp.Header.Get("Docker-Content-Digest"),
+		Size:      resp.ContentLength,
+	}
+	if desc.Digest == "" {
+		// the registry does not return the digest for HEAD, fetch the content
+		_, desc, err = c.getManifest(ctx, ref, resolvableMediaTypes)
+		return desc, err
+	}
+	if ref.Digest != "" && ref.Digest != desc.Digest {
+		return Descriptor{}, fmt.Errorf("digest mismatch, expected %s, got %s", ref.Digest, desc.Digest)
+	}
+	return desc, nil
+}
+
+// FetchManifest downloads and verifies the manifest addressed by the
+// reference
+func (c *Client) FetchManifest(ctx context.Context, ref Reference) (*Manifest, Descriptor, error) {
+	data, desc, err := c.getManifest(ctx, ref, []string{MediaTypeImageManifest})
+	if err != nil {
+		return nil, Descriptor{}, err
+	}
+	manifest := &Manifest{}
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, Descriptor{}, fmt.Errorf("failed to decode manifest %s: %w", ref, err)
+	}
+	if manifest.MediaType != "" && manifest.MediaType != MediaTypeImageManifest {
+		return nil, Descriptor{}, fmt.Errorf("unsupported manifest media type %s", manifest.MediaType)
+	}
+	return manifest, desc, nil
+}
+
+// getManifest downloads the raw manifest and verifies it against the digest
+// in the reference and the response
+func (c *Client) getManifest(ctx context.Context, ref Reference, accept []string) ([]byte, Descriptor, error) {
+	header := http.Header{"Accept": accept}
+	resp, err := c.do(ctx, ref, false, http.MethodGet, c.repositoryURL(ref)+"/manifests/"+ref.Identifier(), header, nil)
+	if err != nil {
+		return nil, Descriptor{}, err
+	}
+	defer closeResponse(resp)
+	if err = checkResponse(resp, http.StatusOK); err != nil {
+		return nil, Descriptor{}, fmt.Errorf("failed to fetch manifest %s: %w", ref, err)
+	}
+	data, err := c.readAll(resp.Body, -1)
+	if err != nil {
+		return nil, Descriptor{}, fmt.Errorf("failed to read manifest %s: %w", ref, err)
+	}
+	for _, expected := range []string{ref.Digest, resp.Header.Get("Docker-Content-Digest")} {
+		if expected != "" {
+			if err = VerifyDigest(expected, data); err != nil {
+				return nil, Descriptor{}, fmt.Errorf("failed to verify manifest %s: %w", ref, err)
+			}
+		}
+	}
+	mediaType := resp.Header.Get("Content-Type")
+	if mediaType == "" {
+		mediaType = MediaTypeImageManifest
+	}
+	return data, Descriptor{MediaType: mediaType, Digest: Digest(data), Size: int64(len(data))}, nil
+}
+
+// FetchBlob downloads the blob described by desc and verifies its size and
+// digest
+func (c *Client) FetchBlob(ctx context.Context, ref Reference, desc Descriptor) ([]byte, error) {
+	if desc.Size > c.maxBlobSize {
+		return nil, fmt.Errorf("blob %s exceeds the max size %d", desc.Digest, c.maxBlobSize)
+	}
+	resp, err := c.do(ctx, ref, false, http.MethodGet, c.repositoryURL(ref)+"/blobs/"+desc.Digest, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponse(resp)
+	if err = checkResponse(resp, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to fetch blob %s: %w", desc.Digest, err)
+	}
+	data, err := c.readAll(resp.Body, desc.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", desc.Digest, err)
+	}
+	if int64(len(data)) != desc.Size {
+		return nil, fmt.Errorf("size mismatch for blob %s, expected %d, got %d", desc.Digest, desc.Size, len(data))
+	}
+	if err = VerifyDigest(desc.Digest, data); err != nil {
+		return nil, fmt.Errorf("failed to verify blob: %w", err)
+	}
+	return data, nil
+}
+
+// Pull downloads the artifact addressed by the reference. All the contents
+// are verified against their digests. The descriptor of the manifest is
+// returned as well.
+func (c *Client) Pull(ctx context.Context, ref Reference) (*Artifact, Descriptor, error) {
+	manifest, desc, err := c.FetchManifest(ctx, ref)
+	if err != nil {
+		return nil, Descriptor{}, err
+	}
+	fetch := func(d Descriptor) (Blob, error) {
+		data, err := c.FetchBlob(ctx, ref, d)
+		return Blob{MediaType: d.MediaType, Data: data, Annotations: d.Annotations}, err
+	}
+	artifact := &Artifact{ArtifactType: manifest.ArtifactType, Annotations: manifest.Annotations}
+	if artifact.Config, err = fetch(manifest.Config); err != nil {
+		return nil, Descriptor{}, err
+	}
+	for _, layer := range manifest.Layers {
+		blob, err := fetch(layer)
+		if err != nil {
+			return nil, Descriptor{}, err
+		}
+		artifact.Layers = append(artifact.Layers, blob)
+	}
+	return artifact, desc, nil
+}
+
+func (c *Client) repositoryURL(ref Reference) string {
+	scheme, host := "https", ref.Registry
+	if c.plainHTTP {
+		scheme = "http"
+	}
+	if host == DefaultRegistry {
+		host = "registry-1.docker.io"
+	}
+	return scheme + "://" + host + "/v2/" + ref.Repository
+}
+
+// readAll reads the body up to size bytes. If size is negative, the max blob
+// size is used as the limit.
+func (c *Client) readAll(r io.Reader, size int64) ([]byte, error) {
+	limit := size
+	if limit < 0 {
+		limit = c.maxBlobSize
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 && int64(len(data)) > limit {
+		return nil, fmt.Errorf("content exceeds the max size %d", limit)
+	}
+	return data, nil
+}
+
+// do sends the request. If the registry requires authorization, the
+// credential will be exchanged according to the challenge and the request
+// will be retried.
+func (c *Client) do(ctx context.Context, ref Reference, push bool, method string, u string, header http.Header, body []byte) (*http.Response, error) {
+	scope := "repository:" + ref.Repository + ":pull"
+	if push {
+		scope += ",push"
+	}
+	key := ref.Registry + "/" + scope
+	send := func(auth string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		return c.httpClient.Do(req)
+	}
+	c.mu.Lock()
+	auth := c.auths[key]
+	c.mu.Unlock()
+	resp, err := send(auth)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	closeResponse(resp)
+	if auth, err = c.authorize(ctx, resp.Header.Get("WWW-Authenticate"), scope); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.auths[key] = auth
+	c.mu.Unlock()
+	return send(auth)
+}
+
+// authorize returns the Authorization header according to the challenge
+func (c *Client) authorize(ctx context.Context, challenge string, scope string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if c.username == "" {
+			return "", fmt.Errorf("registry requires basic auth but no credential is provided")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.username+":"+c.password)), nil
+	case "bearer":
+		realm, err := url.Parse(params["realm"])
+		if err != nil || realm.Host == "" {
+			return "", fmt.Errorf("invalid realm in challenge %q", challenge)
+		}
+		query := realm.Query()
+		if params["service"] != "" {
+			query.Set("service", params["service"])
+		}
+		if params["scope"] != "" {
+			scope = params["scope"]
+		}
+		query.Set("scope", scope)
+		realm.RawQuery = query.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if c.username != "" {
+			req.SetBasicAuth(c.username, c.password)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer closeResponse(resp)
+		if err = checkResponse(resp, http.StatusOK); err != nil {
+			return "", fmt.Errorf("failed to request token: %w", err)
+		}
+		token := struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}{}
+		if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return "", fmt.Errorf("failed to decode token: %w", err)
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		if token.Token == "" {
+			return "", fmt.Errorf("no token returned from %s", realm.Host)
+		}
+		return "Bearer " + token.Token, nil
+	default:
+		return "", fmt.Errorf("unsupported auth challenge %q", challenge)
+	}
+}
+
+// parseChallenge parses the WWW-Authenticate header like
+// Bearer realm="https://auth.example.io/token",service="example.io"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		var k, v string
+		k, rest, _ = strings.Cut(rest, "=")
+		if strings.HasPrefix(rest, `"`) {
+			v, rest, _ = strings.Cut(rest[1:], `"`)
+			_, rest, _ = strings.Cut(rest, ",")
+		} else {
+			v, rest, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(k))] = v
+		rest = strings.TrimSpace(rest)
+	}
+	return strings.ToLower(scheme), params
+}
+
+// checkResponse returns the error if the status code is not expected. The
+// registry errors in the body are included.
+func checkResponse(resp *http.Response, codes ...int) error {
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	errs := struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	if resp.Body != nil && json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&errs) == nil && len(errs.Errors) > 0 {
+		var msgs []string
+		for _, e := range errs.Errors {
+			msgs = append(msgs, e.Code+": "+e.Message)
+		}
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.Join(msgs, "; "))
+	}
+	return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+}
+
+func closeResponse(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistry is an in-memory registry protected by bearer token
+type fakeRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	corrupt   bool
+	server    *httptest.Server
+}
+
+func newFakeRegistry() *fakeRegistry {
+	r := &fakeRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	r.server = httptest.NewServer(r)
+	return r
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if req.URL.Path == "/token" {
+		if user, pass, _ := req.BasicAuth(); user != "alice" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"token":"t0ken"}`)
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer t0ken" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake"`, r.server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	path := strings.TrimPrefix(req.URL.Path, "/v2/example/repo/")
+	body, _ := io.ReadAll(req.Body)
+	switch {
+	case path == "blobs/uploads/" && req.Method == http.MethodPost:
+		w.Header().Set("Location", "/v2/example/repo/blobs/uploads/session?state=x")
+		w.WriteHeader(http.StatusAccepted)
+	case path == "blobs/uploads/session" && req.Method == http.MethodPut:
+		digest := req.URL.Query().Get("digest")
+		if VerifyDigest(digest, body) != nil || req.URL.Query().Get("state") != "x" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, `{"errors":[{"code":"DIGEST_INVALID","message":"digest mismatch"}]}`)
+			return
+		}
+		r.blobs[digest] = body
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(path, "blobs/"):
+		data, found := r.blobs[strings.TrimPrefix(path, "blobs/")]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.corrupt {
+			data = append([]byte("x"), data[1:]...)
+		}
+		_, _ = w.Write(data)
+	case strings.HasPrefix(path, "manifests/") && req.Method == http.MethodPut:
+		r.manifests[strings.TrimPrefix(path, "manifests/")] = body
+		r.manifests[Digest(body)] = body
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(path, "manifests/"):
+		data, found := r.manifests[strings.TrimPrefix(path, "manifests/")]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprintf(w, `{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`)
+			return
+		}
+		w.Header().Set("Content-Type", MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", Digest(data))
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClientPushPull(t *testing.T) {
+	r := require.New(t)
+	registry := newFakeRegistry()
+	defer registry.server.Close()
+	ctx := context.Background()
+	host := strings.TrimPrefix(registry.server.URL, "http://")
+	ref, err := ParseReference(host + "/example/repo:v1")
+	r.NoError(err)
+
+	c := NewClient(WithPlainHTTP(true))
+	_, err = c.Push(ctx, ref, Artifact{})
+	r.Error(err)
+
+	c = NewClient(WithPlainHTTP(true), WithBasicAuth{Username: "alice", Password: "secret"})
+	artifact := Artifact{
+		ArtifactType: "application/vnd.kubevela.manifests",
+		Layers: []Blob{{
+			MediaType:   "application/yaml",
+			Data:        []byte("kind: ConfigMap"),
+			Annotations: map[string]string{"org.opencontainers.image.title": "cm.yaml"},
+		}},
+		Annotations: map[string]string{"app": "example"},
+	}
+	desc, err := c.Push(ctx, ref, artifact)
+	r.NoError(err)
+	r.Equal(MediaTypeImageManifest, desc.MediaType)
+
+	resolved, err := c.Resolve(ctx, ref)
+	r.NoError(err)
+	r.Equal(desc.Digest, resolved.Digest)
+	r.Equal(desc.Size, resolved.Size)
+
+	pulled, pulledDesc, err := c.Pull(ctx, Reference{Registry: host, Repository: "example/repo", Digest: desc.Digest})
+	r.NoError(err)
+	r.Equal(desc, pulledDesc)
+	r.Equal(artifact.ArtifactType, pulled.ArtifactType)
+	r.Equal(artifact.Annotations, pulled.Annotations)
+	r.Equal(MediaTypeEmptyJSON, pulled.Config.MediaType)
+	r.Equal(artifact.Layers, pulled.Layers)
+
+	// pushing the same blobs again is skipped
+	_, err = c.Push(ctx, ref, artifact)
+	r.NoError(err)
+
+	registry.mu.Lock()
+	registry.corrupt = true
+	registry.mu.Unlock()
+	_, _, err = c.Pull(ctx, ref)
+	r.Error(err)
+	r.Contains(err.Error(), "digest mismatch")
+
+	_, _, err = c.Pull(ctx, Reference{Registry: host, Repository: "example/repo", Tag: "unknown"})
+	r.Error(err)
+	r.Contains(err.Error(), "MANIFEST_UNKNOWN")
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.io/token",service="example.io",scope="repository:a/b:pull,push"`)
+	require.Equal(t, "bearer", scheme)
+	require.Equal(t, map[string]string{
+		"realm":   "https://auth.example.io/token",
+		"service": "example.io",
+		"scope":   "repository:a/b:pull,push",
+	}, params)
+	scheme, params = parseChallenge(`Basic realm=registry`)
+	require.Equal(t, "basic", scheme)
+	require.Equal(t, "registry", params["realm"])
+}
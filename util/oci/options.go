@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import "net/http"
+
+// DefaultMaxBlobSize the default max size of the blob to pull
+const DefaultMaxBlobSize = 64 << 20
+
+// ClientOption the option for creating Client
+type ClientOption interface {
+	ApplyToClient(*Client)
+}
+
+// WithBasicAuth set the credential for the registry. It is used for basic
+// auth and for requesting bearer tokens.
+type WithBasicAuth struct {
+	Username string
+	Password string
+}
+
+// ApplyToClient .
+func (op WithBasicAuth) ApplyToClient(c *Client) {
+	c.username, c.password = op.Username, op.Password
+}
+
+// WithPlainHTTP connect to the registry through plain http instead of https
+type WithPlainHTTP bool
+
+// ApplyToClient .
+func (op WithPlainHTTP) ApplyToClient(c *Client) {
+	c.plainHTTP = bool(op)
+}
+
+// WithHTTPClient set the http client for sending requests
+type WithHTTPClient struct {
+	*http.Client
+}
+
+// ApplyToClient .
+func (op WithHTTPClient) ApplyToClient(c *Client) {
+	c.httpClient = op.Client
+}
+
+// WithMaxBlobSize set the max size of the manifest and blobs to pull
+type WithMaxBlobSize int64
+
+// ApplyToClient .
+func (op WithMaxBlobSize) ApplyToClient(c *Client) {
+	c.maxBlobSize = int64(op)
+}
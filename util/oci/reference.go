@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultRegistry the registry used when the reference does not specify one
+	DefaultRegistry = "docker.io"
+	// DefaultTag the tag used when the reference specifies neither tag nor digest
+	DefaultTag = "latest"
+)
+
+var (
+	repositoryRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagRegexp        = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRegexp     = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`)
+)
+
+// Reference identifies an artifact in the registry, in the format of
+// [registry/]repository[:tag][@digest]
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses the reference string. The registry defaults to
+// DefaultRegistry if the first path component does not look like a host, and
+// the tag defaults to DefaultTag if neither tag nor digest is given.
+func ParseReference(s string) (Reference, error) {
+	ref := Reference{}
+	remain := s
+	if i := strings.Index(remain, "@"); i >= 0 {
+		remain, ref.Digest = remain[:i], remain[i+1:]
+		if !digestRegexp.MatchString(ref.Digest) {
+			return ref, fmt.Errorf("invalid digest %q in reference %q", ref.Digest, s)
+		}
+	}
+	// the tag separator must be after the last slash, otherwise it is the port
+	if i := strings.LastIndex(remain, ":"); i >= 0 && i > strings.LastIndex(remain, "/") {
+		remain, ref.Tag = remain[:i], remain[i+1:]
+		if !tagRegexp.MatchString(ref.Tag) {
+			return ref, fmt.Errorf("invalid tag %q in reference %q", ref.Tag, s)
+		}
+	}
+	ref.Registry, ref.Repository = DefaultRegistry, remain
+	if i := strings.Index(remain, "/"); i >= 0 {
+		if host := remain[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry, ref.Repository = host, remain[i+1:]
+		}
+	}
+	if ref.Registry == DefaultRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if !repositoryRegexp.MatchString(ref.Repository) {
+		return ref, fmt.Errorf("invalid repository %q in reference %q", ref.Repository, s)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = DefaultTag
+	}
+	return ref, nil
+}
+
+// String returns the full reference string
+func (ref Reference) String() string {
+	s := ref.Registry + "/" + ref.Repository
+	if ref.Tag != "" {
+		s += ":" + ref.Tag
+	}
+	if ref.Digest != "" {
+		s += "@" + ref.Digest
+	}
+	return s
+}
+
+// Identifier returns the digest if set, otherwise the tag. It is used to
+// address the manifest in the registry.
+func (ref Reference) Identifier() string {
+	if ref.Digest != "" {
+		return ref.Digest
+	}
+	return ref.Tag
+}
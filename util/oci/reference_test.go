@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	digest := "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	testcases := map[string]struct {
+		input    string
+		expected Reference
+		str      string
+		err      bool
+	}{
+		"short": {
+			input:    "nginx",
+			expected: Reference{Registry: DefaultRegistry, Repository: "library/nginx", Tag: DefaultTag},
+			str:      "docker.io/library/nginx:latest",
+		},
+		"registry-with-port": {
+			input:    "localhost:5000/vela/rules:v1",
+			expected: Reference{Registry: "localhost:5000", Repository: "vela/rules", Tag: "v1"},
+			str:      "localhost:5000/vela/rules:v1",
+		},
+		"digest": {
+			input:    "ghcr.io/kubevela/defs@" + digest,
+			expected: Reference{Registry: "ghcr.io", Repository: "kubevela/defs", Digest: digest},
+			str:      "ghcr.io/kubevela/defs@" + digest,
+		},
+		"tag-and-digest": {
+			input:    "ghcr.io/kubevela/defs:v1@" + digest,
+			expected: Reference{Registry: "ghcr.io", Repository: "kubevela/defs", Tag: "v1", Digest: digest},
+			str:      "ghcr.io/kubevela/defs:v1@" + digest,
+		},
+		"bad-repository": {input: "ghcr.io/KubeVela/defs", err: true},
+		"bad-tag":        {input: "ghcr.io/kubevela/defs:!", err: true},
+		"bad-digest":     {input: "ghcr.io/kubevela/defs@sha256", err: true},
+	}
+	for name, tt := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ref, err := ParseReference(tt.input)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, ref)
+			require.Equal(t, tt.str, ref.String())
+		})
+	}
+}
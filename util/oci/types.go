@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MediaTypeImageManifest the media type of the OCI image manifest
+	MediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	// MediaTypeImageIndex the media type of the OCI image index
+	MediaTypeImageIndex = "application/vnd.oci.image.index.v1+json"
+	// MediaTypeDockerManifest the media type of the Docker image manifest
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	// MediaTypeDockerManifestList the media type of the Docker manifest list
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	// MediaTypeEmptyJSON the media type of the empty config for artifacts
+	// without config
+	MediaTypeEmptyJSON = "application/vnd.oci.empty.v1+json"
+)
+
+// resolvableMediaTypes the manifest media types accepted when resolving
+// references
+var resolvableMediaTypes = []string{
+	MediaTypeImageManifest,
+	MediaTypeImageIndex,
+	MediaTypeDockerManifest,
+	MediaTypeDockerManifestList,
+}
+
+// Descriptor describes the content addressed by digest
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest the OCI image manifest
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Blob is a piece of content in the artifact
+type Blob struct {
+	MediaType   string
+	Data        []byte
+	Annotations map[string]string
+}
+
+// Descriptor returns the descriptor of the blob
+func (b Blob) Descriptor() Descriptor {
+	return Descriptor{
+		MediaType:   b.MediaType,
+		Digest:      Digest(b.Data),
+		Size:        int64(len(b.Data)),
+		Annotations: b.Annotations,
+	}
+}
+
+// Artifact is the content pushed to or pulled from the registry, such as
+// rendered manifests or definition packages
+type Artifact struct {
+	// ArtifactType the type of the artifact, recorded in the manifest
+	ArtifactType string
+	// Config the config blob. If the data is empty, the empty JSON config will
+	// be used.
+	Config      Blob
+	Layers      []Blob
+	Annotations map[string]string
+}
+
+// Digest returns the sha256 digest of the data
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// VerifyDigest checks if the data matches the digest. Only sha256 is
+// supported.
+func VerifyDigest(digest string, data []byte) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return fmt.Errorf("unsupported digest algorithm in %q", digest)
+	}
+	if actual := Digest(data); actual != digest {
+		return fmt.Errorf("digest mismatch, expected %s, got %s", digest, actual)
+	}
+	return nil
+}
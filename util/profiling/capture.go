@@ -0,0 +1,163 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Kind the kind of the profile to capture
+type Kind string
+
+const (
+	// KindCPU the cpu profile, captured for a period of time
+	KindCPU Kind = "cpu"
+	// KindHeap the heap profile, captured instantly
+	KindHeap Kind = "heap"
+	// KindGoroutine the goroutine profile, captured instantly
+	KindGoroutine Kind = "goroutine"
+	// KindTrace the execution trace, captured for a period of time
+	KindTrace Kind = "trace"
+)
+
+// DefaultDuration the default duration for capturing cpu profile and trace
+const DefaultDuration = 30 * time.Second
+
+// Capture writes the profile of the given kind into the writer. The duration
+// is only used by cpu profile and execution trace, which block until the
+// duration passed or the context is done.
+func Capture(ctx context.Context, kind Kind, duration time.Duration, w io.Writer) error {
+	switch kind {
+	case KindCPU:
+		if err := pprof.StartCPUProfile(w); err != nil {
+			return err
+		}
+		defer pprof.StopCPUProfile()
+		return sleep(ctx, duration)
+	case KindTrace:
+		if err := trace.Start(w); err != nil {
+			return err
+		}
+		defer trace.Stop()
+		return sleep(ctx, duration)
+	case KindHeap:
+		runtime.GC()
+		return pprof.Lookup("heap").WriteTo(w, 0)
+	case KindGoroutine:
+		return pprof.Lookup("goroutine").WriteTo(w, 0)
+	default:
+		return fmt.Errorf("unknown profile kind %s", kind)
+	}
+}
+
+func sleep(ctx context.Context, duration time.Duration) error {
+	select {
+	case <-time.After(duration):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sink stores the captured profiles
+type Sink interface {
+	Store(ctx context.Context, name string, data io.Reader) error
+}
+
+// FileSink stores the captured profiles as files under the directory
+type FileSink string
+
+// Store writes the profile into file
+func (s FileSink) Store(ctx context.Context, name string, data io.Reader) error {
+	if err := os.MkdirAll(string(s), 0750); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(string(s), filepath.Base(name)))
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Capturer captures profiles and stores them into the sink
+type Capturer struct {
+	Sink Sink
+	// Duration the duration for capturing cpu profile and trace. If not set,
+	// DefaultDuration will be used.
+	Duration time.Duration
+}
+
+// Capture captures the profile and returns the name of the stored profile
+func (c *Capturer) Capture(ctx context.Context, kind Kind) (string, error) {
+	duration := c.Duration
+	if duration <= 0 {
+		duration = DefaultDuration
+	}
+	buf := &bytes.Buffer{}
+	if err := Capture(ctx, kind, duration, buf); err != nil {
+		return "", fmt.Errorf("failed to capture %s profile: %w", kind, err)
+	}
+	ext := "prof"
+	if kind == KindTrace {
+		ext = "out"
+	}
+	name := fmt.Sprintf("%s-%d-%s.%s", kind, os.Getpid(), time.Now().Format("20060102150405"), ext)
+	if err := c.Sink.Store(ctx, name, buf); err != nil {
+		return "", fmt.Errorf("failed to store %s profile: %w", kind, err)
+	}
+	return name, nil
+}
+
+// CaptureOnSignal captures the profiles of given kinds every time the signal
+// is received, until the context is done
+func (c *Capturer) CaptureOnSignal(ctx context.Context, sig os.Signal, kinds ...Kind) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				for _, kind := range kinds {
+					if name, err := c.Capture(ctx, kind); err != nil {
+						klog.ErrorS(err, "failed to capture profile on signal", "kind", kind)
+					} else {
+						klog.InfoS("profile captured on signal", "kind", kind, "name", name)
+					}
+				}
+			}
+		}
+	}()
+}
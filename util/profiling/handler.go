@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiling
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+// Authorizer decides whether the request is allowed to access the profiling
+// endpoints
+type Authorizer func(req *http.Request) bool
+
+// LoopbackOnly only allows requests from the loopback address
+func LoopbackOnly(req *http.Request) bool {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// BearerToken only allows requests carrying the token in the Authorization
+// header
+func BearerToken(token string) Authorizer {
+	return func(req *http.Request) bool {
+		provided := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		return len(token) > 0 && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+	}
+}
+
+// NewHandler create the handler serving the pprof endpoints under
+// /debug/pprof/ and the capture endpoint under /debug/capture. The capture
+// endpoint accepts POST requests with the "kind" query parameter and stores
+// the profile through the capturer. If authorizer is nil, LoopbackOnly will
+// be used.
+func NewHandler(capturer *Capturer, authorizer Authorizer) http.Handler {
+	if authorizer == nil {
+		authorizer = LoopbackOnly
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	if capturer != nil {
+		mux.HandleFunc("/debug/capture", func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			name, err := capturer.Capture(req.Context(), Kind(req.URL.Query().Get("kind")))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			_, _ = fmt.Fprintln(w, name)
+		})
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !authorizer(req) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, req)
+	})
+}
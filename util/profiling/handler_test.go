@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiling_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/profiling"
+)
+
+func TestHandler(t *testing.T) {
+	dir := t.TempDir()
+	capturer := &profiling.Capturer{Sink: profiling.FileSink(dir), Duration: 10 * time.Millisecond}
+	handler := profiling.NewHandler(capturer, profiling.BearerToken("secret"))
+
+	serve := func(method string, target string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/debug/pprof/", "").Code)
+	require.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/debug/pprof/", "wrong").Code)
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/debug/pprof/", "secret").Code)
+	require.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodGet, "/debug/capture?kind=heap", "secret").Code)
+	require.Equal(t, http.StatusInternalServerError, serve(http.MethodPost, "/debug/capture?kind=unknown", "secret").Code)
+
+	for _, kind := range []string{"heap", "cpu", "trace"} {
+		w := serve(http.MethodPost, "/debug/capture?kind="+kind, "secret")
+		require.Equal(t, http.StatusOK, w.Code)
+		info, err := os.Stat(filepath.Join(dir, strings.TrimSpace(w.Body.String())))
+		require.NoError(t, err)
+		require.NotZero(t, info.Size())
+	}
+}
+
+func TestLoopbackOnly(t *testing.T) {
+	handler := profiling.NewHandler(nil, nil)
+	req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	req.RemoteAddr = "127.0.0.1:1234"
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+}
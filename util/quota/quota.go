@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Quota the limits on the objects of the kind owned by one tenant. Zero
+// means unlimited.
+type Quota struct {
+	GroupVersionKind schema.GroupVersionKind
+	// MaxCount the max number of objects
+	MaxCount int64
+	// MaxBytes the max total size of the objects in JSON. Limiting the size
+	// requires listing the full objects instead of the metadata only.
+	MaxBytes int64
+}
+
+// Usage the objects of the kind owned by one tenant
+type Usage struct {
+	GroupVersionKind schema.GroupVersionKind
+	Count            int64
+	// Bytes the total size of the objects in JSON, only counted for kinds
+	// with MaxBytes
+	Bytes int64
+}
+
+// ViolationType the type of the exceeded limit
+type ViolationType string
+
+const (
+	// ViolationCount the number of objects exceeds MaxCount
+	ViolationCount ViolationType = "count"
+	// ViolationBytes the size of objects exceeds MaxBytes
+	ViolationBytes ViolationType = "bytes"
+)
+
+// Violation the usage exceeding the quota
+type Violation struct {
+	Tenant           string
+	GroupVersionKind schema.GroupVersionKind
+	Type             ViolationType
+	Used             int64
+	Limit            int64
+}
+
+// String .
+func (v Violation) String() string {
+	return fmt.Sprintf("tenant %s exceeds %s quota of %s: %d > %d", v.Tenant, v.Type, v.GroupVersionKind.Kind, v.Used, v.Limit)
+}
+
+// Report the usages and violations of one tenant
+type Report struct {
+	Tenant     string
+	Usages     []Usage
+	Violations []Violation
+}
+
+// ViolationError the error for quota violations
+type ViolationError struct {
+	Violations []Violation
+}
+
+// Error .
+func (e *ViolationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+	return fmt.Sprintf("quota exceeded: [%s]", strings.Join(msgs, ", "))
+}
+
+// Err returns *ViolationError if there is any violation, otherwise nil
+func (r *Report) Err() error {
+	if len(r.Violations) == 0 {
+		return nil
+	}
+	return &ViolationError{Violations: r.Violations}
+}
+
+// Check counts the objects owned by the tenant, which are marked by the
+// tenant label, and compares the usages against the quotas
+func Check(ctx context.Context, cli client.Client, tenantLabel string, tenant string, quotas []Quota) (*Report, error) {
+	usages, err := collectUsages(ctx, cli, tenantLabel, client.MatchingLabels{tenantLabel: tenant}, quotas)
+	if err != nil {
+		return nil, err
+	}
+	return newReport(tenant, usages[tenant], quotas), nil
+}
+
+// CheckAll counts the objects of all the tenants and compares the usages
+// against the quotas. Reports are sorted by tenant.
+func CheckAll(ctx context.Context, cli client.Client, tenantLabel string, quotas []Quota) ([]Report, error) {
+	usages, err := collectUsages(ctx, cli, tenantLabel, client.HasLabels{tenantLabel}, quotas)
+	if err != nil {
+		return nil, err
+	}
+	tenants := make([]string, 0, len(usages))
+	for tenant := range usages {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	reports := make([]Report, 0, len(tenants))
+	for _, tenant := range tenants {
+		reports = append(reports, *newReport(tenant, usages[tenant], quotas))
+	}
+	return reports, nil
+}
+
+// CheckAdmission checks if the tenant of the object still fits the quotas
+// after the object is created. It is used by admission webhooks, and returns
+// *ViolationError if any quota would be exceeded. Objects without the tenant
+// label are not limited.
+func CheckAdmission(ctx context.Context, cli client.Client, tenantLabel string, obj client.Object, quotas []Quota) error {
+	tenant, found := obj.GetLabels()[tenantLabel]
+	if !found {
+		return nil
+	}
+	gvk, err := apiutil.GVKForObject(obj, cli.Scheme())
+	if err != nil {
+		return err
+	}
+	var matched []Quota
+	for _, q := range quotas {
+		if q.GroupVersionKind == gvk {
+			matched = append(matched, q)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	report, err := Check(ctx, cli, tenantLabel, tenant, matched)
+	if err != nil {
+		return err
+	}
+	bs, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	for i := range report.Usages {
+		report.Usages[i].Count++
+		if matched[i].MaxBytes > 0 {
+			report.Usages[i].Bytes += int64(len(bs))
+		}
+	}
+	return newReport(tenant, report.Usages, matched).Err()
+}
+
+// collectUsages counts the objects matching the selector by tenants
+func collectUsages(ctx context.Context, cli client.Client, tenantLabel string, selector client.ListOption, quotas []Quota) (map[string][]Usage, error) {
+	usages := map[string][]Usage{}
+	add := func(i int, tenant string, bytes int64) {
+		if _, found := usages[tenant]; !found {
+			usages[tenant] = make([]Usage, len(quotas))
+			for j, q := range quotas {
+				usages[tenant][j].GroupVersionKind = q.GroupVersionKind
+			}
+		}
+		usages[tenant][i].Count++
+		usages[tenant][i].Bytes += bytes
+	}
+	for i, q := range quotas {
+		listGVK := q.GroupVersionKind.GroupVersion().WithKind(q.GroupVersionKind.Kind + "List")
+		if q.MaxBytes > 0 {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(listGVK)
+			if err := cli.List(ctx, list, selector); err != nil {
+				return nil, fmt.Errorf("failed to list %s: %w", q.GroupVersionKind.Kind, err)
+			}
+			for _, item := range list.Items {
+				bs, err := item.MarshalJSON()
+				if err != nil {
+					return nil, err
+				}
+				add(i, item.GetLabels()[tenantLabel], int64(len(bs)))
+			}
+			continue
+		}
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(listGVK)
+		if err := cli.List(ctx, list, selector); err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", q.GroupVersionKind.Kind, err)
+		}
+		for _, item := range list.Items {
+			add(i, item.GetLabels()[tenantLabel], 0)
+		}
+	}
+	return usages, nil
+}
+
+func newReport(tenant string, usages []Usage, quotas []Quota) *Report {
+	report := &Report{Tenant: tenant, Usages: usages}
+	if report.Usages == nil {
+		report.Usages = make([]Usage, len(quotas))
+		for i, q := range quotas {
+			report.Usages[i].GroupVersionKind = q.GroupVersionKind
+		}
+	}
+	for i, q := range quotas {
+		usage := report.Usages[i]
+		if q.MaxCount > 0 && usage.Count > q.MaxCount {
+			report.Violations = append(report.Violations, Violation{Tenant: tenant, GroupVersionKind: q.GroupVersionKind,
+				Type: ViolationCount, Used: usage.Count, Limit: q.MaxCount})
+		}
+		if q.MaxBytes > 0 && usage.Bytes > q.MaxBytes {
+			report.Violations = append(report.Violations, Violation{Tenant: tenant, GroupVersionKind: q.GroupVersionKind,
+				Type: ViolationBytes, Used: usage.Bytes, Limit: q.MaxBytes})
+		}
+	}
+	return report
+}
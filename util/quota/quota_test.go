@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const tenantLabel = "tenant.oam.dev/name"
+
+func TestCheck(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	newCM := func(name, tenant string) client.Object {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default",
+			Labels: map[string]string{tenantLabel: tenant}}, Data: map[string]string{"key": "value"}}
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		newCM("a-1", "a"), newCM("a-2", "a"), newCM("a-3", "a"), newCM("b-1", "b"),
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "none", Namespace: "default"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "a-1", Namespace: "default",
+			Labels: map[string]string{tenantLabel: "a"}}},
+	).Build()
+	quotas := []Quota{
+		{GroupVersionKind: corev1.SchemeGroupVersion.WithKind("ConfigMap"), MaxCount: 2},
+		{GroupVersionKind: corev1.SchemeGroupVersion.WithKind("Secret"), MaxCount: 1, MaxBytes: 1024},
+	}
+
+	report, err := Check(ctx, cli, tenantLabel, "a", quotas)
+	r.NoError(err)
+	r.Equal(int64(3), report.Usages[0].Count)
+	r.Equal(int64(1), report.Usages[1].Count)
+	r.Greater(report.Usages[1].Bytes, int64(0))
+	r.Equal([]Violation{{Tenant: "a", GroupVersionKind: quotas[0].GroupVersionKind, Type: ViolationCount, Used: 3, Limit: 2}}, report.Violations)
+	violationErr := &ViolationError{}
+	r.True(errors.As(report.Err(), &violationErr))
+
+	report, err = Check(ctx, cli, tenantLabel, "c", quotas)
+	r.NoError(err)
+	r.Equal(int64(0), report.Usages[0].Count)
+	r.NoError(report.Err())
+
+	reports, err := CheckAll(ctx, cli, tenantLabel, quotas)
+	r.NoError(err)
+	r.Len(reports, 2)
+	r.Equal("a", reports[0].Tenant)
+	r.Equal("b", reports[1].Tenant)
+	r.Equal(int64(1), reports[1].Usages[0].Count)
+	r.Equal(int64(0), reports[1].Usages[1].Count)
+	r.NoError(reports[1].Err())
+
+	r.Error(CheckAdmission(ctx, cli, tenantLabel, newCM("a-4", "a"), quotas))
+	r.NoError(CheckAdmission(ctx, cli, tenantLabel, newCM("b-2", "b"), quotas))
+	r.NoError(CheckAdmission(ctx, cli, tenantLabel, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "x"}}, quotas))
+	err = CheckAdmission(ctx, cli, tenantLabel, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "a-2",
+		Labels: map[string]string{tenantLabel: "a"}}}, quotas)
+	r.True(errors.As(err, &violationErr))
+	r.Equal(ViolationCount, violationErr.Violations[0].Type)
+	r.NoError(CheckAdmission(ctx, cli, tenantLabel, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p",
+		Labels: map[string]string{tenantLabel: "a"}}}, quotas))
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kubevela/pkg/util/oci"
+)
+
+const (
+	// MediaTypeSimpleSigning the media type of the signed payload layer
+	MediaTypeSimpleSigning = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// AnnotationSignature the layer annotation carrying the base64 encoded
+	// signature
+	AnnotationSignature = "dev.cosignproject.cosign/signature"
+	// SimpleSigningType the type of the signed payload
+	SimpleSigningType = "cosign container image signature"
+)
+
+// Payload the signed payload in the simple signing format used by cosign
+type Payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]string `json:"optional"`
+}
+
+// NewPayload create the payload for the manifest digest of the repository
+func NewPayload(ref oci.Reference, digest string, annotations map[string]string) *Payload {
+	p := &Payload{Optional: annotations}
+	p.Critical.Identity.DockerReference = dockerReference(ref)
+	p.Critical.Image.DockerManifestDigest = digest
+	p.Critical.Type = SimpleSigningType
+	return p
+}
+
+// dockerReference returns the identity of the repository in the payload
+func dockerReference(ref oci.Reference) string {
+	return ref.Registry + "/" + ref.Repository
+}
+
+// SignatureTag returns the tag where cosign stores the signatures of the
+// manifest digest, like sha256-<hex>.sig
+func SignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// SignArtifact signs the manifest addressed by ref with the key and pushes
+// the signature next to it in the cosign layout. The annotations are recorded
+// in the payload. The digest of the signed manifest is returned.
+func SignArtifact(ctx context.Context, c *oci.Client, ref oci.Reference, key crypto.Signer, annotations map[string]string) (string, error) {
+	desc, err := c.Resolve(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(NewPayload(ref, desc.Digest, annotations))
+	if err != nil {
+		return "", err
+	}
+	sig, err := SignBlob(key, payload)
+	if err != nil {
+		return "", err
+	}
+	layers := []oci.Blob{{
+		MediaType:   MediaTypeSimpleSigning,
+		Data:        payload,
+		Annotations: map[string]string{AnnotationSignature: sig},
+	}}
+	sigRef := oci.Reference{Registry: ref.Registry, Repository: ref.Repository, Tag: SignatureTag(desc.Digest)}
+	// keep the signatures pushed before, such as the ones from other keys
+	if existing, _, err := c.Pull(ctx, sigRef); err == nil {
+		for _, layer := range existing.Layers {
+			if layer.Annotations[AnnotationSignature] != sig {
+				layers = append(layers, layer)
+			}
+		}
+	}
+	if _, err = c.Push(ctx, sigRef, oci.Artifact{Layers: layers}); err != nil {
+		return "", fmt.Errorf("failed to push signature: %w", err)
+	}
+	return desc.Digest, nil
+}
+
+// VerifyArtifact checks if the manifest addressed by ref is signed by the key
+// in the cosign layout and returns the verified payload. The payload must be
+// signed for the repository of ref, so that signatures copied from other
+// images are rejected.
+func VerifyArtifact(ctx context.Context, c *oci.Client, ref oci.Reference, key crypto.PublicKey) (*Payload, error) {
+	desc, err := c.Resolve(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	sigRef := oci.Reference{Registry: ref.Registry, Repository: ref.Repository, Tag: SignatureTag(desc.Digest)}
+	signatures, _, err := c.Pull(ctx, sigRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signatures of %s: %w", ref, err)
+	}
+	var errs []string
+	for _, layer := range signatures.Layers {
+		sig, found := layer.Annotations[AnnotationSignature]
+		if layer.MediaType != MediaTypeSimpleSigning || !found {
+			continue
+		}
+		if err = VerifyBlob(key, layer.Data, sig); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		payload := &Payload{}
+		if err = json.Unmarshal(layer.Data, payload); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid payload: %s", err.Error()))
+			continue
+		}
+		if payload.Critical.Image.DockerManifestDigest != desc.Digest {
+			errs = append(errs, fmt.Sprintf("payload digest %s does not match %s", payload.Critical.Image.DockerManifestDigest, desc.Digest))
+			continue
+		}
+		if identity := dockerReference(ref); payload.Critical.Identity.DockerReference != identity {
+			errs = append(errs, fmt.Sprintf("payload reference %s does not match %s", payload.Critical.Identity.DockerReference, identity))
+			continue
+		}
+		return payload, nil
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no signature found for %s", ref)
+	}
+	return nil, fmt.Errorf("no valid signature found for %s: [%s]", ref, strings.Join(errs, ", "))
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/oci"
+)
+
+// newRegistry starts an in-memory registry for the example/repo repository
+func newRegistry() *httptest.Server {
+	mu := sync.Mutex{}
+	contents := map[string][]byte{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		path := strings.TrimPrefix(req.URL.Path, "/v2/example/repo/")
+		body, _ := io.ReadAll(req.Body)
+		switch {
+		case req.Method == http.MethodPost:
+			w.Header().Set("Location", "/v2/example/repo/blobs/uploads/session")
+			w.WriteHeader(http.StatusAccepted)
+		case req.Method == http.MethodPut && strings.HasPrefix(path, "blobs/"):
+			contents[req.URL.Query().Get("digest")] = body
+			w.WriteHeader(http.StatusCreated)
+		case req.Method == http.MethodPut:
+			contents[path] = body
+			contents["manifests/"+oci.Digest(body)] = body
+			w.WriteHeader(http.StatusCreated)
+		default:
+			data, found := contents[path]
+			if !found {
+				data, found = contents[strings.TrimPrefix(path, "blobs/")]
+			}
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if strings.HasPrefix(path, "manifests/") {
+				w.Header().Set("Docker-Content-Digest", oci.Digest(data))
+			}
+			if req.Method == http.MethodGet {
+				_, _ = w.Write(data)
+			}
+		}
+	}))
+}
+
+func TestSignArtifact(t *testing.T) {
+	r := require.New(t)
+	server := newRegistry()
+	defer server.Close()
+	ctx := context.Background()
+	c := oci.NewClient(oci.WithPlainHTTP(true))
+	ref, err := oci.ParseReference(strings.TrimPrefix(server.URL, "http://") + "/example/repo:v1")
+	r.NoError(err)
+	_, err = c.Push(ctx, ref, oci.Artifact{Layers: []oci.Blob{{MediaType: "application/yaml", Data: []byte("kind: ConfigMap")}}})
+	r.NoError(err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	r.NoError(err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	r.NoError(err)
+
+	_, err = VerifyArtifact(ctx, c, ref, key.Public())
+	r.Error(err)
+
+	digest, err := SignArtifact(ctx, c, ref, key, map[string]string{"signer": "ci"})
+	r.NoError(err)
+	_, err = SignArtifact(ctx, c, ref, other, nil)
+	r.NoError(err)
+
+	for _, k := range []*ecdsa.PrivateKey{key, other} {
+		payload, err := VerifyArtifact(ctx, c, ref, k.Public())
+		r.NoError(err)
+		r.Equal(digest, payload.Critical.Image.DockerManifestDigest)
+		r.Equal(ref.Registry+"/example/repo", payload.Critical.Identity.DockerReference)
+		r.Equal(SimpleSigningType, payload.Critical.Type)
+	}
+
+	stranger, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	r.NoError(err)
+	_, err = VerifyArtifact(ctx, c, ref, stranger.Public())
+	r.Error(err)
+	r.Contains(err.Error(), "no valid signature")
+
+	// signatures for other repositories are rejected
+	payload, err := json.Marshal(NewPayload(oci.Reference{Registry: ref.Registry, Repository: "example/other"}, digest, nil))
+	r.NoError(err)
+	sig, err := SignBlob(stranger, payload)
+	r.NoError(err)
+	sigRef := oci.Reference{Registry: ref.Registry, Repository: ref.Repository, Tag: SignatureTag(digest)}
+	_, err = c.Push(ctx, sigRef, oci.Artifact{Layers: []oci.Blob{{
+		MediaType: MediaTypeSimpleSigning, Data: payload, Annotations: map[string]string{AnnotationSignature: sig}}}})
+	r.NoError(err)
+	_, err = VerifyArtifact(ctx, c, ref, stranger.Public())
+	r.Error(err)
+	r.Contains(err.Error(), "payload reference "+ref.Registry+"/example/other does not match")
+
+	r.Equal("sha256-abc.sig", SignatureTag("sha256:abc"))
+}
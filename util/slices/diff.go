@@ -0,0 +1,53 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slices
+
+// DiffResult the partitions of items compared between two slices
+type DiffResult[T any] struct {
+	// Added the items in the new slice but not in the old one
+	Added []T
+	// Removed the items in the old slice but not in the new one
+	Removed []T
+	// Kept the items in both slices, taken from the new slice
+	Kept []T
+}
+
+// Diff compares the old and new slices by the key of items. The order of the
+// items in each partition follows the slice it is taken from.
+func Diff[T any, K comparable](old []T, new []T, key func(T) K) DiffResult[T] {
+	result := DiffResult[T]{}
+	oldKeys := make(map[K]struct{}, len(old))
+	for _, item := range old {
+		oldKeys[key(item)] = struct{}{}
+	}
+	newKeys := make(map[K]struct{}, len(new))
+	for _, item := range new {
+		k := key(item)
+		newKeys[k] = struct{}{}
+		if _, found := oldKeys[k]; found {
+			result.Kept = append(result.Kept, item)
+		} else {
+			result.Added = append(result.Added, item)
+		}
+	}
+	for _, item := range old {
+		if _, found := newKeys[key(item)]; !found {
+			result.Removed = append(result.Removed, item)
+		}
+	}
+	return result
+}
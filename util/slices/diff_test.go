@@ -0,0 +1,43 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slices_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/slices"
+)
+
+func TestDiff(t *testing.T) {
+	type resource struct {
+		Name    string
+		Version int
+	}
+	old := []resource{{"a", 1}, {"b", 1}, {"c", 1}}
+	updated := []resource{{"d", 1}, {"b", 2}, {"a", 1}}
+	result := slices.Diff(old, updated, func(r resource) string { return r.Name })
+	require.Equal(t, []resource{{"d", 1}}, result.Added)
+	require.Equal(t, []resource{{"c", 1}}, result.Removed)
+	require.Equal(t, []resource{{"b", 2}, {"a", 1}}, result.Kept)
+
+	ints := slices.Diff(nil, []int{1, 2}, func(i int) int { return i })
+	require.Equal(t, []int{1, 2}, ints.Added)
+	require.Empty(t, ints.Removed)
+	require.Empty(t, ints.Kept)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slices
+
+// Iterator lazily yields the items of a sequence. The second return value
+// will be false when the sequence is exhausted.
+// Iterators can be chained by Filter, Map and Take so that no intermediate
+// slice is materialized before Collect is called.
+type Iterator[T any] func() (T, bool)
+
+// Iter create an iterator over the items
+func Iter[T any](items []T) Iterator[T] {
+	idx := 0
+	return func() (item T, ok bool) {
+		if idx >= len(items) {
+			return item, false
+		}
+		idx++
+		return items[idx-1], true
+	}
+}
+
+// Filter yields the items that satisfy the predicate
+func Filter[T any](it Iterator[T], predicate func(T) bool) Iterator[T] {
+	return func() (T, bool) {
+		for {
+			item, ok := it()
+			if !ok || predicate(item) {
+				return item, ok
+			}
+		}
+	}
+}
+
+// Map yields the items converted by the mapper
+func Map[T any, R any](it Iterator[T], mapper func(T) R) Iterator[R] {
+	return func() (r R, ok bool) {
+		item, ok := it()
+		if !ok {
+			return r, false
+		}
+		return mapper(item), true
+	}
+}
+
+// Take yields at most n items. The underlying iterator will not be advanced
+// after n items are taken.
+func Take[T any](it Iterator[T], n int) Iterator[T] {
+	taken := 0
+	return func() (item T, ok bool) {
+		if taken >= n {
+			return item, false
+		}
+		if item, ok = it(); ok {
+			taken++
+		}
+		return item, ok
+	}
+}
+
+// Collect drains the iterator into a slice
+func Collect[T any](it Iterator[T]) []T {
+	var items []T
+	for item, ok := it(); ok; item, ok = it() {
+		items = append(items, item)
+	}
+	return items
+}
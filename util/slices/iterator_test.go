@@ -0,0 +1,41 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slices_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/slices"
+)
+
+func TestIterator(t *testing.T) {
+	visited := 0
+	it := slices.Iter([]int{1, 2, 3, 4, 5, 6, 7, 8})
+	it = slices.Filter(it, func(i int) bool {
+		visited++
+		return i%2 == 0
+	})
+	out := slices.Collect(slices.Take(slices.Map(it, strconv.Itoa), 2))
+	require.Equal(t, []string{"2", "4"}, out)
+	require.Equal(t, 4, visited)
+
+	require.Empty(t, slices.Collect(slices.Iter([]int{})))
+	require.Empty(t, slices.Collect(slices.Take(slices.Iter([]int{1}), 0)))
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slices
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// RandomSeed returns a seed varying on each call, for the sampling that is not
+// expected to be deterministic
+func RandomSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// newRand creates the random source of the seed
+func newRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// Shuffle returns the shuffled copy of the items. The same seed always gives
+// the same order for the same items. Use RandomSeed for random orders.
+func Shuffle[T any](items []T, seed int64) []T {
+	shuffled := make([]T, len(items))
+	copy(shuffled, items)
+	r := newRand(seed)
+	r.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return shuffled
+}
+
+// Sample picks n items at random without replacement, in the order they are
+// picked. All items are returned in a shuffled order if n exceeds the number
+// of items. The same seed always gives the same result for the same items.
+// Use RandomSeed for random results.
+func Sample[T any](items []T, n int, seed int64) []T {
+	if n <= 0 {
+		return []T{}
+	}
+	shuffled := Shuffle(items, seed)
+	if n > len(shuffled) {
+		n = len(shuffled)
+	}
+	return shuffled[:n]
+}
+
+// WeightedSample picks n items at random without replacement, where the
+// chance of each item to be picked is proportional to its weight. Items with
+// zero weight are never picked, so fewer than n items are returned if there
+// are not enough items with positive weights. The same seed always gives the
+// same result for the same items. Use RandomSeed for random results.
+func WeightedSample[T any](items []T, weights []float64, n int, seed int64) ([]T, error) {
+	if len(items) != len(weights) {
+		return nil, fmt.Errorf("the number of weights %d does not match the number of items %d", len(weights), len(items))
+	}
+	type candidate struct {
+		index int
+		key   float64
+	}
+	r := newRand(seed)
+	candidates := make([]candidate, 0, len(items))
+	for i, weight := range weights {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("invalid weight %v for item %d", weight, i)
+		}
+		if weight == 0 {
+			continue
+		}
+		// Efraimidis-Spirakis: picking the largest keys u^(1/w) is equivalent
+		// to picking items one by one proportionally to their weights
+		candidates = append(candidates, candidate{index: i, key: math.Pow(r.Float64(), 1/weight)})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].key > candidates[j].key })
+	if n < 0 {
+		n = 0
+	}
+	if n > len(candidates) {
+		n = len(candidates)
+	}
+	sampled := make([]T, 0, n)
+	for _, c := range candidates[:n] {
+		sampled = append(sampled, items[c.index])
+	}
+	return sampled, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slices_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/slices"
+)
+
+func TestSample(t *testing.T) {
+	r := require.New(t)
+	items := []string{"a", "b", "c", "d", "e", "f"}
+	sampled := slices.Sample(items, 3, 42)
+	r.Len(sampled, 3)
+	r.Equal(sampled, slices.Sample(items, 3, 42))
+	r.Subset(items, sampled)
+	r.ElementsMatch(items, slices.Sample(items, 10, 42))
+	r.Empty(slices.Sample(items, 0, 42))
+	r.Len(slices.Sample(items, 2, slices.RandomSeed()), 2)
+	// seed 0 is as deterministic as the others
+	r.Equal(slices.Sample(items, 6, 0), slices.Sample(items, 6, 0))
+	r.Equal([]string{"a", "b", "c", "d", "e", "f"}, items)
+
+	shuffled := slices.Shuffle(items, 7)
+	r.ElementsMatch(items, shuffled)
+	r.Equal(shuffled, slices.Shuffle(items, 7))
+}
+
+func TestWeightedSample(t *testing.T) {
+	r := require.New(t)
+	items := []string{"a", "b", "c", "d"}
+	weights := []float64{1, 0, 100, 1}
+	sampled, err := slices.WeightedSample(items, weights, 2, 42)
+	r.NoError(err)
+	r.Len(sampled, 2)
+	r.NotContains(sampled, "b")
+	again, err := slices.WeightedSample(items, weights, 2, 42)
+	r.NoError(err)
+	r.Equal(sampled, again)
+
+	sampled, err = slices.WeightedSample(items, weights, 10, slices.RandomSeed())
+	r.NoError(err)
+	r.ElementsMatch([]string{"a", "c", "d"}, sampled)
+
+	heavy := 0
+	for seed := int64(1); seed <= 100; seed++ {
+		sampled, err = slices.WeightedSample(items, weights, 1, seed)
+		r.NoError(err)
+		if sampled[0] == "c" {
+			heavy++
+		}
+	}
+	r.Greater(heavy, 90)
+
+	_, err = slices.WeightedSample(items, []float64{1}, 1, 42)
+	r.Error(err)
+	_, err = slices.WeightedSample(items, []float64{1, -1, 1, 1}, 1, 42)
+	r.Error(err)
+}
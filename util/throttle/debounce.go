@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttle
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// debounceTriggerCounter counts the triggers received by debouncers
+	debounceTriggerCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubevela_debounce_triggers_total",
+		Help: "number of triggers received by the debouncer",
+	}, []string{"debouncer"})
+	// debounceExecutionCounter counts the executions made by debouncers
+	debounceExecutionCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubevela_debounce_executions_total",
+		Help: "number of executions made by the debouncer after coalescing triggers",
+	}, []string{"debouncer"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(debounceTriggerCounter, debounceExecutionCounter)
+}
+
+// Debouncer coalesces bursts of triggers with the same key into one execution
+type Debouncer struct {
+	name    string
+	maxWait time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*debounceCall
+}
+
+type debounceCall struct {
+	timer    *time.Timer
+	deadline time.Time
+	fn       func()
+}
+
+// NewDebouncer create a Debouncer. The name is used as the label of metrics.
+// If maxWait is positive, the execution for a key will not be delayed more
+// than maxWait since its first trigger, even if triggers keep coming.
+func NewDebouncer(name string, maxWait time.Duration) *Debouncer {
+	return &Debouncer{
+		name:    name,
+		maxWait: maxWait,
+		pending: map[string]*debounceCall{},
+	}
+}
+
+// Debounce schedules fn to be executed after window. If the same key is
+// triggered again before the execution, the execution is postponed to window
+// after the latest trigger and the latest fn will be executed instead.
+func (d *Debouncer) Debounce(key string, window time.Duration, fn func()) {
+	debounceTriggerCounter.WithLabelValues(d.name).Inc()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	if call, found := d.pending[key]; found {
+		call.fn = fn
+		delay := window
+		if !call.deadline.IsZero() && now.Add(delay).After(call.deadline) {
+			delay = call.deadline.Sub(now)
+		}
+		call.timer.Reset(delay)
+		return
+	}
+	call := &debounceCall{fn: fn}
+	if d.maxWait > 0 {
+		call.deadline = now.Add(d.maxWait)
+	}
+	call.timer = time.AfterFunc(window, func() { d.execute(key, call) })
+	d.pending[key] = call
+}
+
+func (d *Debouncer) execute(key string, call *debounceCall) {
+	d.mu.Lock()
+	if d.pending[key] != call {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, key)
+	fn := call.fn
+	d.mu.Unlock()
+	debounceExecutionCounter.WithLabelValues(d.name).Inc()
+	fn()
+}
+
+// Cancel drops the pending execution of the key
+func (d *Debouncer) Cancel(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if call, found := d.pending[key]; found {
+		call.timer.Stop()
+		delete(d.pending, key)
+	}
+}
+
+var defaultDebouncer = NewDebouncer("default", 0)
+
+// Debounce coalesces the triggers of the key with the default debouncer which
+// has no max wait
+func Debounce(key string, window time.Duration, fn func()) {
+	defaultDebouncer.Debounce(key, window, fn)
+}
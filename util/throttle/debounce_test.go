@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttle_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/throttle"
+)
+
+func TestDebounce(t *testing.T) {
+	var a, b, latest int32
+	for i := int32(1); i <= 5; i++ {
+		v := i
+		throttle.Debounce("a", 50*time.Millisecond, func() {
+			atomic.AddInt32(&a, 1)
+			atomic.StoreInt32(&latest, v)
+		})
+	}
+	throttle.Debounce("b", 10*time.Millisecond, func() { atomic.AddInt32(&b, 1) })
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&a) == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, int32(5), atomic.LoadInt32(&latest))
+	require.Equal(t, int32(1), atomic.LoadInt32(&b))
+	time.Sleep(60 * time.Millisecond)
+	require.Equal(t, int32(1), atomic.LoadInt32(&a))
+}
+
+func TestDebouncerMaxWait(t *testing.T) {
+	// keep triggering within the window, the execution still happens after
+	// the max wait
+	d := throttle.NewDebouncer("test", 50*time.Millisecond)
+	var count int32
+	stop := time.After(200 * time.Millisecond)
+	for loop := true; loop; {
+		select {
+		case <-stop:
+			loop = false
+		default:
+			d.Debounce("key", 20*time.Millisecond, func() { atomic.AddInt32(&count, 1) })
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	require.GreaterOrEqual(t, atomic.LoadInt32(&count), int32(2))
+	d.Cancel("key")
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// environment variables following the OpenTelemetry specification
+const (
+	EnvServiceName        = "OTEL_SERVICE_NAME"
+	EnvResourceAttributes = "OTEL_RESOURCE_ATTRIBUTES"
+	EnvEndpoint           = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvInsecure           = "OTEL_EXPORTER_OTLP_INSECURE"
+	EnvHeaders            = "OTEL_EXPORTER_OTLP_HEADERS"
+	EnvSampler            = "OTEL_TRACES_SAMPLER"
+	EnvSamplerArg         = "OTEL_TRACES_SAMPLER_ARG"
+)
+
+// Sampler the sampler policy of the spans
+type Sampler string
+
+const (
+	// SamplerAlwaysOn samples all the spans
+	SamplerAlwaysOn Sampler = "always_on"
+	// SamplerAlwaysOff samples no span
+	SamplerAlwaysOff Sampler = "always_off"
+	// SamplerTraceIDRatio samples the ratio of traces
+	SamplerTraceIDRatio Sampler = "traceidratio"
+	// SamplerParentBasedAlwaysOn follows the parent span, and samples all
+	// the root spans
+	SamplerParentBasedAlwaysOn Sampler = "parentbased_always_on"
+	// SamplerParentBasedAlwaysOff follows the parent span, and samples no
+	// root span
+	SamplerParentBasedAlwaysOff Sampler = "parentbased_always_off"
+	// SamplerParentBasedTraceIDRatio follows the parent span, and samples
+	// the ratio of root spans
+	SamplerParentBasedTraceIDRatio Sampler = "parentbased_traceidratio"
+)
+
+// Config the config for setting up tracing
+type Config struct {
+	// ServiceName the service.name of the resource
+	ServiceName string
+	// ResourceAttributes the extra attributes of the resource
+	ResourceAttributes map[string]string
+	// Endpoint the OTLP gRPC endpoint in the format of host:port. If empty,
+	// spans are not exported.
+	Endpoint string
+	// Insecure disables the transport security to the endpoint
+	Insecure bool
+	// Headers the headers sent to the endpoint
+	Headers map[string]string
+	// Sampler the sampler policy, defaults to SamplerParentBasedAlwaysOn
+	Sampler Sampler
+	// SamplerRatio the ratio for the trace ID ratio based samplers
+	SamplerRatio float64
+}
+
+// NewConfigFromEnv loads the config from the OpenTelemetry environment
+// variables. The service name defaults to the given one if not set.
+func NewConfigFromEnv(serviceName string) (Config, error) {
+	cfg := Config{
+		ServiceName:  serviceName,
+		Endpoint:     os.Getenv(EnvEndpoint),
+		Sampler:      SamplerParentBasedAlwaysOn,
+		SamplerRatio: 1,
+	}
+	if name := os.Getenv(EnvServiceName); name != "" {
+		cfg.ServiceName = name
+	}
+	var err error
+	if cfg.ResourceAttributes, err = parseKeyValues(os.Getenv(EnvResourceAttributes)); err != nil {
+		return cfg, fmt.Errorf("invalid %s: %w", EnvResourceAttributes, err)
+	}
+	if cfg.Headers, err = parseKeyValues(os.Getenv(EnvHeaders)); err != nil {
+		return cfg, fmt.Errorf("invalid %s: %w", EnvHeaders, err)
+	}
+	// the scheme of the endpoint decides the transport security
+	switch {
+	case strings.HasPrefix(cfg.Endpoint, "http://"):
+		cfg.Endpoint, cfg.Insecure = strings.TrimPrefix(cfg.Endpoint, "http://"), true
+	case strings.HasPrefix(cfg.Endpoint, "https://"):
+		cfg.Endpoint = strings.TrimPrefix(cfg.Endpoint, "https://")
+	}
+	if insecure := os.Getenv(EnvInsecure); insecure != "" {
+		if cfg.Insecure, err = strconv.ParseBool(insecure); err != nil {
+			return cfg, fmt.Errorf("invalid %s: %w", EnvInsecure, err)
+		}
+	}
+	if sampler := os.Getenv(EnvSampler); sampler != "" {
+		cfg.Sampler = Sampler(sampler)
+	}
+	if arg := os.Getenv(EnvSamplerArg); arg != "" {
+		if cfg.SamplerRatio, err = strconv.ParseFloat(arg, 64); err != nil {
+			return cfg, fmt.Errorf("invalid %s: %w", EnvSamplerArg, err)
+		}
+	}
+	return cfg, nil
+}
+
+// parseKeyValues parses the comma-separated key=value pairs
+func parseKeyValues(s string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid key value pair %q", pair)
+		}
+		m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return m, nil
+}
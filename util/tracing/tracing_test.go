@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"k8s.io/client-go/rest"
+)
+
+func TestNewConfigFromEnv(t *testing.T) {
+	r := require.New(t)
+	cfg, err := NewConfigFromEnv("vela-core")
+	r.NoError(err)
+	r.Equal(Config{ServiceName: "vela-core", Sampler: SamplerParentBasedAlwaysOn, SamplerRatio: 1,
+		ResourceAttributes: map[string]string{}, Headers: map[string]string{}}, cfg)
+
+	t.Setenv(EnvServiceName, "controller")
+	t.Setenv(EnvResourceAttributes, "k8s.namespace.name=vela-system, team=vela")
+	t.Setenv(EnvEndpoint, "http://collector:4317")
+	t.Setenv(EnvHeaders, "api-key=secret")
+	t.Setenv(EnvSampler, string(SamplerTraceIDRatio))
+	t.Setenv(EnvSamplerArg, "0.25")
+	cfg, err = NewConfigFromEnv("vela-core")
+	r.NoError(err)
+	r.Equal(Config{
+		ServiceName:        "controller",
+		ResourceAttributes: map[string]string{"k8s.namespace.name": "vela-system", "team": "vela"},
+		Endpoint:           "collector:4317",
+		Insecure:           true,
+		Headers:            map[string]string{"api-key": "secret"},
+		Sampler:            SamplerTraceIDRatio,
+		SamplerRatio:       0.25,
+	}, cfg)
+
+	t.Setenv(EnvInsecure, "false")
+	cfg, err = NewConfigFromEnv("vela-core")
+	r.NoError(err)
+	r.False(cfg.Insecure)
+	t.Setenv(EnvInsecure, "maybe")
+	_, err = NewConfigFromEnv("vela-core")
+	r.Error(err)
+	t.Setenv(EnvInsecure, "")
+	t.Setenv(EnvResourceAttributes, "broken")
+	_, err = NewConfigFromEnv("vela-core")
+	r.Error(err)
+}
+
+func TestSetupAndTransport(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	_, err := Setup(ctx, Config{Sampler: "unknown"})
+	r.Error(err)
+	for _, sampler := range []Sampler{SamplerAlwaysOn, SamplerAlwaysOff, SamplerTraceIDRatio,
+		SamplerParentBasedAlwaysOff, SamplerParentBasedTraceIDRatio} {
+		_, err = newSampler(Config{Sampler: sampler})
+		r.NoError(err)
+	}
+	shutdown, err := Setup(ctx, Config{ServiceName: "test", Sampler: SamplerAlwaysOn})
+	r.NoError(err)
+	defer func() { r.NoError(shutdown(ctx)) }()
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		traceparent = req.Header.Get("traceparent")
+	}))
+	defer server.Close()
+	cfg := WrapConfig(&rest.Config{Host: server.URL})
+	rt, err := rest.TransportFor(cfg)
+	r.NoError(err)
+
+	ctx, span := otel.Tracer("test").Start(ctx, "parent")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/namespaces", nil)
+	r.NoError(err)
+	resp, err := rt.RoundTrip(req)
+	r.NoError(err)
+	r.NoError(resp.Body.Close())
+	span.End()
+	r.Contains(traceparent, span.SpanContext().TraceID().String())
+}
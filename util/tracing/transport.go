@@ -0,0 +1,43 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// NewTransportWrapper create a WrapperFunc for wrapping the client-go
+// RoundTripper, which records the span of each request and propagates the
+// trace context from the request context to the API server
+func NewTransportWrapper() transport.WrapperFunc {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return otelhttp.NewTransport(rt, otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+			return "kubernetes " + req.Method
+		}))
+	}
+}
+
+// WrapConfig returns the copy of the config with requests traced
+func WrapConfig(cfg *rest.Config) *rest.Config {
+	wrapped := rest.CopyConfig(cfg)
+	wrapped.Wrap(NewTransportWrapper())
+	return wrapped
+}